@@ -0,0 +1,170 @@
+package fsutil
+
+import (
+	"errors"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+var (
+	errAtomicWriterClosed = errors.New("the atomic writer is already committed or aborted")
+	errAtomicWriteDir     = errors.New("the directory can't be written atomically")
+)
+
+// AtomicWriter an io.WriteCloser that writes the data to a temporary file in the same directory as the target file,
+// then replaces the target file with it atomically on Commit, so the target file is either unchanged or completely
+// written even if the process crashes. The recommended usage is calling the Abort with defer and calling the Commit
+// after all the data is written
+type AtomicWriter struct {
+	f    *os.File
+	path string
+	// target the stat of the existing target file to preserve its mode and ownership, nil if it does not exist
+	target fs.FileInfo
+	// err the first error of writing the data
+	err  error
+	done bool
+}
+
+// NewAtomicWriter create an AtomicWriter to write the path. If the target file exists, its mode and ownership are
+// preserved as far as the permission allows, otherwise the new file is created with the perm (before umask).
+// If the path is a symbolic link, the file it points to is replaced and the link is kept
+func NewAtomicWriter(path string, perm fs.FileMode) (*AtomicWriter, error) {
+	return newAtomicWriter(path, perm, true)
+}
+
+// newAtomicWriter create an AtomicWriter like NewAtomicWriter if the preserve is true. Otherwise the existing target
+// is replaced by a new file with the perm, and if the path is a symbolic link, the link itself is replaced instead of
+// the file it points to
+func newAtomicWriter(path string, perm fs.FileMode, preserve bool) (*AtomicWriter, error) {
+	stat := os.Lstat
+	if preserve {
+		stat = os.Stat
+	}
+	target, err := stat(path)
+	if err != nil && !isNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if target.IsDir() {
+			return nil, errAtomicWriteDir
+		}
+		if !preserve {
+			target = nil
+		} else if path, err = filepath.EvalSymlinks(path); err != nil {
+			return nil, err
+		} else {
+			perm = target.Mode().Perm()
+		}
+	}
+	f, err := createTempFile(path, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicWriter{
+		f:      f,
+		path:   path,
+		target: target,
+	}, nil
+}
+
+// createTempFile create a new temporary file in the same directory as the path, it is like os.CreateTemp,
+// but creates the file with the perm instead of 0600
+func createTempFile(path string, perm fs.FileMode) (f *os.File, err error) {
+	dir, name := filepath.Split(path)
+	for i := 0; i < 10000; i++ {
+		tmp := filepath.Join(dir, "."+name+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp")
+		f, err = os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+	return nil, err
+}
+
+// Write writes the data to the temporary file
+func (w *AtomicWriter) Write(p []byte) (n int, err error) {
+	if w.done {
+		return 0, errAtomicWriterClosed
+	}
+	n, err = w.f.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// Commit fsync the temporary file, rename it over the target file, then fsync the parent directory to persist the rename.
+// If any write failed, abort and return the first write error instead, the target file is unchanged
+func (w *AtomicWriter) Commit() (err error) {
+	if w.done {
+		return errAtomicWriterClosed
+	}
+	if w.err != nil {
+		w.Abort()
+		return w.err
+	}
+	w.done = true
+	name := w.f.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(name)
+		}
+	}()
+	if w.target != nil {
+		// change the owner first, the chown may clear the setuid and setgid bits
+		if err = chownLike(w.f, w.target); err != nil {
+			w.f.Close()
+			return err
+		}
+		if err = w.f.Chmod(chmodBits(w.target.Mode())); err != nil {
+			w.f.Close()
+			return err
+		}
+	}
+	if err = w.f.Sync(); err != nil {
+		w.f.Close()
+		return err
+	}
+	// the file must be closed before renaming on Windows
+	if err = w.f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(name, w.path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(w.path))
+}
+
+// Abort close and remove the temporary file, the target file is unchanged.
+// It does nothing if the AtomicWriter is already committed or aborted
+func (w *AtomicWriter) Abort() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	return errors.Join(w.f.Close(), os.Remove(w.f.Name()))
+}
+
+// Close commit the data like Commit, but does nothing if the AtomicWriter is already committed or aborted
+func (w *AtomicWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	return w.Commit()
+}
+
+// WriteFileAtomic writes the data to the file like os.WriteFile, but replaces the file atomically with an AtomicWriter
+func WriteFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	w, err := NewAtomicWriter(path, perm)
+	if err != nil {
+		return err
+	}
+	defer w.Abort()
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	return w.Commit()
+}
@@ -0,0 +1,246 @@
+package fsutil
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "atomic.txt")
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"create file", "hello gopher"},
+		{"replace file", "hello world"},
+		{"replace with empty data", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := WriteFileAtomic(path, []byte(tc.data), 0600); err != nil {
+				t.Errorf("test WriteFileAtomic error => %v", err)
+				return
+			}
+			testAtomicFileContent(t, path, tc.data)
+			testNoTempFile(t, dir)
+		})
+	}
+}
+
+func TestWriteFileAtomic_PreserveMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the file mode is not fully supported on Windows")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "atomic.txt")
+	if err := os.WriteFile(path, []byte("hello gopher"), 0600); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Errorf("change file mode error => %v", err)
+		return
+	}
+	if err := WriteFileAtomic(path, []byte("hello world"), 0777); err != nil {
+		t.Errorf("test WriteFileAtomic error => %v", err)
+		return
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+	if stat.Mode().Perm() != 0640 {
+		t.Errorf("test WriteFileAtomic error, expect to preserve the mode %v, but actual get %v", os.FileMode(0640), stat.Mode().Perm())
+	}
+	testAtomicFileContent(t, path, "hello world")
+}
+
+func TestWriteFileAtomic_Symlink(t *testing.T) {
+	if !IsSymlinkSupported() {
+		t.Skip("the symbolic link is unsupported")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "atomic.txt")
+	link := filepath.Join(dir, "atomic.link")
+	if err := os.WriteFile(path, []byte("hello gopher"), 0600); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	if err := Symlink(path, link); err != nil {
+		t.Errorf("create symbolic link error => %v", err)
+		return
+	}
+	if err := WriteFileAtomic(link, []byte("hello world"), 0600); err != nil {
+		t.Errorf("test WriteFileAtomic error => %v", err)
+		return
+	}
+	if isSymlink, err := IsSymlink(link); err != nil || !isSymlink {
+		t.Errorf("test WriteFileAtomic error, expect to keep the symbolic link, err=%v", err)
+	}
+	testAtomicFileContent(t, path, "hello world")
+}
+
+func TestWriteFileAtomic_ReturnError(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name   string
+		path   string
+		expect error
+	}{
+		{"not exist directory", filepath.Join(dir, "not_exist", "atomic.txt"), os.ErrNotExist},
+		{"directory", dir, errAtomicWriteDir},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := WriteFileAtomic(tc.path, []byte("hello gopher"), 0600); !errors.Is(err, tc.expect) {
+				t.Errorf("test WriteFileAtomic error, expect to get %v, but actual get %v", tc.expect, err)
+			}
+		})
+	}
+	testNoTempFile(t, dir)
+}
+
+func TestAtomicWriter_Abort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "atomic.txt")
+	if err := os.WriteFile(path, []byte("hello gopher"), 0600); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	w, err := NewAtomicWriter(path, 0600)
+	if err != nil {
+		t.Errorf("test NewAtomicWriter error => %v", err)
+		return
+	}
+	if _, err = io.WriteString(w, "hello world"); err != nil {
+		t.Errorf("test Write error => %v", err)
+		return
+	}
+	if err = w.Abort(); err != nil {
+		t.Errorf("test Abort error => %v", err)
+		return
+	}
+	testAtomicFileContent(t, path, "hello gopher")
+	testNoTempFile(t, dir)
+
+	if _, err = w.Write([]byte("hello")); !errors.Is(err, errAtomicWriterClosed) {
+		t.Errorf("test Write error, expect to get %v, but actual get %v", errAtomicWriterClosed, err)
+	}
+	if err = w.Commit(); !errors.Is(err, errAtomicWriterClosed) {
+		t.Errorf("test Commit error, expect to get %v, but actual get %v", errAtomicWriterClosed, err)
+	}
+	if err = w.Abort(); err != nil {
+		t.Errorf("test Abort error, expect to do nothing after aborted, but actual get %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Errorf("test Close error, expect to do nothing after aborted, but actual get %v", err)
+	}
+}
+
+func TestAtomicWriter_Close(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "atomic.txt")
+	var w io.WriteCloser
+	w, err := NewAtomicWriter(path, 0600)
+	if err != nil {
+		t.Errorf("test NewAtomicWriter error => %v", err)
+		return
+	}
+	if _, err = io.WriteString(w, "hello gopher"); err != nil {
+		t.Errorf("test Write error => %v", err)
+		return
+	}
+	if exist, _ := FileExist(path); exist {
+		t.Errorf("test AtomicWriter error, expect the target file does not exist before committed")
+	}
+	if err = w.Close(); err != nil {
+		t.Errorf("test Close error => %v", err)
+		return
+	}
+	testAtomicFileContent(t, path, "hello gopher")
+	testNoTempFile(t, dir)
+
+	// the failed write aborts the AtomicWriter
+	aw, err := NewAtomicWriter(path, 0600)
+	if err != nil {
+		t.Errorf("test NewAtomicWriter error => %v", err)
+		return
+	}
+	aw.f.Close()
+	if _, err = aw.Write([]byte("hello world")); err == nil {
+		t.Errorf("test Write error, expect to get an error but get nil")
+		return
+	}
+	if err = aw.Close(); err == nil {
+		t.Errorf("test Close error, expect to get the write error but get nil")
+	}
+	testAtomicFileContent(t, path, "hello gopher")
+	testNoTempFile(t, dir)
+}
+
+func TestAtomicWriter_CommitAfterWriteError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "atomic.txt")
+	if err := os.WriteFile(path, []byte("hello gopher"), 0600); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	w, err := NewAtomicWriter(path, 0600)
+	if err != nil {
+		t.Errorf("test NewAtomicWriter error => %v", err)
+		return
+	}
+	defer w.Abort()
+	if _, err = io.WriteString(w, "hello"); err != nil {
+		t.Errorf("test Write error => %v", err)
+		return
+	}
+	// reopen the temporary file as read-only to make the next write fail, but the file can still be synced and renamed
+	ro, err := os.Open(w.f.Name())
+	if err != nil {
+		t.Errorf("open temporary file error => %v", err)
+		return
+	}
+	w.f.Close()
+	w.f = ro
+	_, writeErr := io.WriteString(w, " world")
+	if writeErr == nil {
+		t.Errorf("test Write error, expect to get an error but get nil")
+		return
+	}
+	if err = w.Commit(); !errors.Is(err, writeErr) {
+		t.Errorf("test Commit error, expect to get the write error %v, but actual get %v", writeErr, err)
+	}
+	testAtomicFileContent(t, path, "hello gopher")
+	testNoTempFile(t, dir)
+}
+
+func testAtomicFileContent(t *testing.T, path string, expect string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	if string(data) != expect {
+		t.Errorf("expect to get the content %q, but actual get %q", expect, data)
+	}
+}
+
+func testNoTempFile(t *testing.T, dir string) {
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if err != nil {
+		t.Errorf("glob temporary files error => %v", err)
+		return
+	}
+	if len(matches) > 0 {
+		t.Errorf("expect to remove all the temporary files, but actual get %v", matches)
+	}
+}
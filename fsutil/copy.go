@@ -0,0 +1,292 @@
+package fsutil
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+const (
+	// OverwriteError return an error that matches the fs.ErrExist if the destination file exists
+	OverwriteError OverwritePolicy = iota
+	// OverwriteSkip keep the existing destination file and skip copying the source file
+	OverwriteSkip
+	// OverwriteAlways replace the existing destination file
+	OverwriteAlways
+	// OverwriteIfNewer replace the existing destination file only if the source file is modified after it
+	OverwriteIfNewer
+)
+
+var (
+	errCopyDir       = errors.New("the directory can't be copied as a file, use CopyDir instead")
+	errCopyNotDir    = errors.New("the source of CopyDir must be a directory")
+	errCopyToSub     = errors.New("can't copy the directory to itself or its subdirectory")
+	errCopyIrregular = errors.New("only the regular files, directories and symbolic links can be copied")
+)
+
+// OverwritePolicy the policy to handle the existing destination files when copying
+type OverwritePolicy int
+
+// CopyOptions the options of CopyFile and CopyDir
+type CopyOptions struct {
+	// Overwrite the policy to handle the existing destination files
+	Overwrite OverwritePolicy
+	// FollowSymlinks copy the targets of the symbolic links instead of the links themselves
+	FollowSymlinks bool
+	// Include the glob patterns of the files to copy in the CopyDir, empty means all files.
+	// The pattern matches the slash-separated relative path or the base name, like path.Match
+	Include []string
+	// Exclude the glob patterns of the files and directories to ignore in the CopyDir, take precedence over the Include
+	Exclude []string
+	// PreserveHardLinks detect the files that are hard links to the same file in the CopyDir,
+	// and create them as hard links in the destination too instead of copying the data repeatedly.
+	// It is unsupported on Windows
+	PreserveHardLinks bool
+}
+
+// CopyFile copy the file to the dst, and preserve the mode, access time, modify time and ownership as far as the
+// permission allows. The data is written to the dst atomically, and cloned with the reflink or copied in the kernel
+// with copy_file_range on Linux if the file system supports it. The symbolic link is copied as a link unless
+// the FollowSymlinks is true, the filters and hard links options are only for the CopyDir.
+// If the dst is an existing symbolic link, the link itself is replaced, the file it points to is unchanged
+func CopyFile(src, dst string, opts CopyOptions) error {
+	c := newCopier(opts)
+	stat, err := c.stat(src)
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		return errCopyDir
+	}
+	return c.copy(src, dst, stat)
+}
+
+// CopyDir copy the directory tree to the dst recursively like CopyFile, the existing destination directories are
+// merged, and the irregular files like the named pipes and sockets are ignored
+func CopyDir(src, dst string, opts CopyOptions) error {
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	stat, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return errCopyNotDir
+	}
+	realSrc, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return err
+	}
+	isSub, err := IsSub(realSrc, dst)
+	if err != nil {
+		return err
+	}
+	if isSub {
+		return errCopyToSub
+	}
+	c := newCopier(opts)
+	c.visited[realSrc] = true
+	return c.copyDir(src, dst, "", stat)
+}
+
+type copier struct {
+	opts CopyOptions
+	// links the destination paths of the copied files that have multiple hard links
+	links map[fileID]string
+	// visited the real paths of the directories that are copied, avoid the symbolic link loop
+	visited map[string]bool
+}
+
+func newCopier(opts CopyOptions) *copier {
+	return &copier{
+		opts:    opts,
+		links:   make(map[fileID]string),
+		visited: make(map[string]bool),
+	}
+}
+
+func (c *copier) stat(name string) (fs.FileInfo, error) {
+	if c.opts.FollowSymlinks {
+		return os.Stat(name)
+	}
+	return os.Lstat(name)
+}
+
+func (c *copier) copy(src, dst string, stat fs.FileInfo) error {
+	switch {
+	case IsSymlinkMode(stat.Mode()):
+		return c.copySymlink(src, dst, stat)
+	case stat.Mode().IsRegular():
+		return c.copyFile(src, dst, stat)
+	}
+	return errCopyIrregular
+}
+
+// copyDir copy the entries of the directory recursively, then set the metadata of the directory,
+// because creating the entries changes the modify time of the directory
+func (c *copier) copyDir(src, dst, relDir string, stat fs.FileInfo) error {
+	// keep the directory writable until all the entries are copied
+	if err := os.Mkdir(dst, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, d := range entries {
+		name := filepath.Join(src, d.Name())
+		rel := path.Join(relDir, d.Name())
+		if MatchAny(c.opts.Exclude, rel) {
+			continue
+		}
+		stat, err := c.stat(name)
+		if err != nil {
+			return err
+		}
+		if stat.IsDir() {
+			err = c.copySubDir(name, filepath.Join(dst, d.Name()), rel, stat, IsSymlinkMode(d.Type()))
+		} else if (stat.Mode().IsRegular() || IsSymlinkMode(stat.Mode())) && c.included(rel) {
+			err = c.copy(name, filepath.Join(dst, d.Name()), stat)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return setMetadata(dst, stat)
+}
+
+func (c *copier) copySubDir(src, dst, rel string, stat fs.FileInfo, isSymlink bool) error {
+	if isSymlink {
+		realPath, err := filepath.EvalSymlinks(src)
+		if err != nil {
+			return err
+		}
+		if c.visited[realPath] {
+			return nil
+		}
+		c.visited[realPath] = true
+	}
+	return c.copyDir(src, dst, rel, stat)
+}
+
+func (c *copier) copyFile(src, dst string, stat fs.FileInfo) error {
+	if skip, err := c.skip(stat, dst); skip || err != nil {
+		return err
+	}
+	id, isHardLink := getHardLinkIDBySys(stat.Sys())
+	isHardLink = isHardLink && c.opts.PreserveHardLinks
+	if first, ok := c.links[id]; isHardLink && ok {
+		if err := removeExisting(dst); err != nil {
+			return err
+		}
+		return os.Link(first, dst)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := newAtomicWriter(dst, 0600, false)
+	if err != nil {
+		return err
+	}
+	defer w.Abort()
+	if err = copyFileData(w.f, f); err != nil {
+		return err
+	}
+	if err = setMetadata(w.f.Name(), stat); err != nil {
+		return err
+	}
+	if err = w.Commit(); err != nil {
+		return err
+	}
+	if isHardLink {
+		c.links[id] = dst
+	}
+	return nil
+}
+
+func (c *copier) copySymlink(src, dst string, stat fs.FileInfo) error {
+	if skip, err := c.skip(stat, dst); skip || err != nil {
+		return err
+	}
+	target, err := Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err = removeExisting(dst); err != nil {
+		return err
+	}
+	if err = Symlink(target, dst); err != nil {
+		return err
+	}
+	if err = lchownLike(dst, stat); err != nil {
+		return err
+	}
+	_, aTime, mTime, err := GetFileTimeBySys(stat.Sys())
+	if err != nil {
+		return err
+	}
+	return SetSymlinkFileTime(dst, aTime, mTime)
+}
+
+// skip whether to skip copying the source file to the existing dst according to the overwrite policy
+func (c *copier) skip(stat fs.FileInfo, dst string) (bool, error) {
+	dstStat, err := os.Lstat(dst)
+	if isNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	switch c.opts.Overwrite {
+	case OverwriteSkip:
+		return true, nil
+	case OverwriteAlways:
+		return false, nil
+	case OverwriteIfNewer:
+		return !stat.ModTime().After(dstStat.ModTime()), nil
+	}
+	return false, &fs.PathError{Op: "copy", Path: dst, Err: fs.ErrExist}
+}
+
+func (c *copier) included(rel string) bool {
+	return len(c.opts.Include) == 0 || MatchAny(c.opts.Include, rel)
+}
+
+// removeExisting remove the file if it exists, the directory is not removed
+func removeExisting(name string) error {
+	stat, err := os.Lstat(name)
+	if isNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		return errCopyDir
+	}
+	return os.Remove(name)
+}
+
+// setMetadata set the ownership, mode, access time and modify time of the stat to the file,
+// the ownership is ignored if the current user is not allowed to change it
+func setMetadata(name string, stat fs.FileInfo) error {
+	if err := lchownLike(name, stat); err != nil {
+		return err
+	}
+	if err := os.Chmod(name, chmodBits(stat.Mode())); err != nil {
+		return err
+	}
+	_, aTime, mTime, err := GetFileTimeBySys(stat.Sys())
+	if err != nil {
+		return err
+	}
+	return SetFileTime(name, aTime, mTime)
+}
@@ -0,0 +1,49 @@
+package fsutil
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// copyFileRangeSize the max count of bytes to copy with the copy_file_range every time
+	copyFileRangeSize = 1 << 30
+)
+
+// copyFileData clone the data of the src to the dst with the FICLONE if the file system supports the reflink, like
+// btrfs and xfs, otherwise copy the data in the kernel with the copy_file_range, and fall back to io.Copy if both are
+// unsupported, e.g. copying across the file systems on the old kernels
+func copyFileData(dst *os.File, src *os.File) error {
+	if unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())) == nil {
+		return nil
+	}
+	var written int64
+	for {
+		n, err := unix.CopyFileRange(int(src.Fd()), nil, int(dst.Fd()), nil, copyFileRangeSize, 0)
+		if n > 0 {
+			written += int64(n)
+			continue
+		}
+		if err == nil {
+			// reach the end of the src
+			return nil
+		}
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if written == 0 && isCopyFileRangeUnsupported(err) {
+			break
+		}
+		return err
+	}
+	_, err := io.Copy(dst, src)
+	return err
+}
+
+func isCopyFileRangeUnsupported(err error) bool {
+	return errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EXDEV) || errors.Is(err, unix.EINVAL) ||
+		errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EPERM)
+}
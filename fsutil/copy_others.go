@@ -0,0 +1,14 @@
+//go:build !linux
+
+package fsutil
+
+import (
+	"io"
+	"os"
+)
+
+// copyFileData copy the data of the src to the dst
+func copyFileData(dst *os.File, src *os.File) error {
+	_, err := io.Copy(dst, src)
+	return err
+}
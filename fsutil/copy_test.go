@@ -0,0 +1,374 @@
+package fsutil
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+var (
+	testCopyModTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+)
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	newTestCopyFile(t, src, "hello gopher", testCopyModTime)
+	if err := CopyFile(src, dst, CopyOptions{}); err != nil {
+		t.Errorf("test CopyFile error => %v", err)
+		return
+	}
+	testAtomicFileContent(t, dst, "hello gopher")
+	testCopyMetadata(t, src, dst)
+	testNoTempFile(t, dir)
+}
+
+func TestCopyFile_Overwrite(t *testing.T) {
+	testCases := []struct {
+		name      string
+		overwrite OverwritePolicy
+		srcTime   time.Time
+		expect    string
+		err       error
+	}{
+		{"error", OverwriteError, testCopyModTime, "hello world", fs.ErrExist},
+		{"skip", OverwriteSkip, testCopyModTime, "hello world", nil},
+		{"always", OverwriteAlways, testCopyModTime, "hello gopher", nil},
+		{"if newer with older source", OverwriteIfNewer, testCopyModTime.Add(-time.Hour), "hello world", nil},
+		{"if newer with newer source", OverwriteIfNewer, testCopyModTime.Add(time.Hour), "hello gopher", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src.txt")
+			dst := filepath.Join(dir, "dst.txt")
+			newTestCopyFile(t, src, "hello gopher", tc.srcTime)
+			newTestCopyFile(t, dst, "hello world", testCopyModTime)
+			if err := CopyFile(src, dst, CopyOptions{Overwrite: tc.overwrite}); !errors.Is(err, tc.err) {
+				t.Errorf("test CopyFile error, expect to get %v, but actual get %v", tc.err, err)
+				return
+			}
+			testAtomicFileContent(t, dst, tc.expect)
+		})
+	}
+}
+
+func TestCopyFile_Symlink(t *testing.T) {
+	if !IsSymlinkSupported() {
+		t.Skip("the symbolic link is unsupported")
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	link := filepath.Join(dir, "src.link")
+	newTestCopyFile(t, src, "hello gopher", testCopyModTime)
+	if err := Symlink(src, link); err != nil {
+		t.Errorf("create symbolic link error => %v", err)
+		return
+	}
+	linkModTime := testCopyModTime.Add(time.Hour)
+	if err := SetSymlinkFileTime(link, linkModTime, linkModTime); err != nil {
+		t.Errorf("set symbolic link file time error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name      string
+		follow    bool
+		isSymlink bool
+		modTime   time.Time
+	}{
+		{"copy link", false, true, linkModTime},
+		{"follow link", true, false, testCopyModTime},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "dst")
+			if err := CopyFile(link, dst, CopyOptions{FollowSymlinks: tc.follow}); err != nil {
+				t.Errorf("test CopyFile error => %v", err)
+				return
+			}
+			if isSymlink, err := IsSymlink(dst); err != nil || isSymlink != tc.isSymlink {
+				t.Errorf("test CopyFile error, expect the symbolic link is %v, but actual get %v, err=%v", tc.isSymlink, isSymlink, err)
+			}
+			if _, _, mTime, err := GetFileTime(dst); err != nil || !mTime.Equal(tc.modTime) {
+				t.Errorf("test CopyFile error, expect the modify time is %v, but actual get %v, err=%v", tc.modTime, mTime, err)
+			}
+			testAtomicFileContent(t, dst, "hello gopher")
+		})
+	}
+}
+
+func TestCopyFile_SymlinkDst(t *testing.T) {
+	if !IsSymlinkSupported() {
+		t.Skip("the symbolic link is unsupported")
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	target := filepath.Join(dir, "target.txt")
+	dst := filepath.Join(dir, "dst.link")
+	newTestCopyFile(t, src, "hello gopher", testCopyModTime)
+	newTestCopyFile(t, target, "old", testCopyModTime)
+	if err := Symlink(target, dst); err != nil {
+		t.Errorf("create symbolic link error => %v", err)
+		return
+	}
+
+	if err := CopyFile(src, dst, CopyOptions{Overwrite: OverwriteAlways}); err != nil {
+		t.Errorf("test CopyFile error => %v", err)
+		return
+	}
+	if isSymlink, err := IsSymlink(dst); err != nil || isSymlink {
+		t.Errorf("test CopyFile error, expect the symbolic link is replaced by the file, but actual get symbolic link=%v, err=%v", isSymlink, err)
+	}
+	testAtomicFileContent(t, dst, "hello gopher")
+	testAtomicFileContent(t, target, "old")
+}
+
+func TestCopyFile_ReturnError(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name   string
+		src    string
+		dst    string
+		expect error
+	}{
+		{"not exist source", filepath.Join(dir, "not_exist.txt"), filepath.Join(dir, "dst.txt"), os.ErrNotExist},
+		{"directory source", dir, filepath.Join(dir, "dst.txt"), errCopyDir},
+		{"not exist destination directory", testExistFilePath, filepath.Join(dir, "not_exist", "dst.txt"), os.ErrNotExist},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := CopyFile(tc.src, tc.dst, CopyOptions{}); !errors.Is(err, tc.expect) {
+				t.Errorf("test CopyFile error, expect to get %v, but actual get %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	src := newTestCopyDir(t)
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := CopyDir(src, dst, CopyOptions{}); err != nil {
+		t.Errorf("test CopyDir error => %v", err)
+		return
+	}
+	testCopyDirFiles(t, dst, []string{"a.txt", "b.go", "sub", "sub/c.txt", "sub/deep", "sub/deep/d.go"})
+	testAtomicFileContent(t, filepath.Join(dst, "sub", "deep", "d.go"), "package deep")
+	for _, name := range []string{"a.txt", "sub", "sub/deep", "sub/deep/d.go"} {
+		testCopyMetadata(t, filepath.Join(src, name), filepath.Join(dst, name))
+	}
+	testCopyMetadata(t, src, dst)
+}
+
+func TestCopyDir_Filter(t *testing.T) {
+	src := newTestCopyDir(t)
+	testCases := []struct {
+		name    string
+		include []string
+		exclude []string
+		expect  []string
+	}{
+		{"include", []string{"*.go"}, nil, []string{"b.go", "sub", "sub/deep", "sub/deep/d.go"}},
+		{"exclude", nil, []string{"deep", "a.txt"}, []string{"b.go", "sub", "sub/c.txt"}},
+		{"include and exclude", []string{"*.txt"}, []string{"sub/c.txt"}, []string{"a.txt", "sub", "sub/deep"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "dst")
+			if err := CopyDir(src, dst, CopyOptions{Include: tc.include, Exclude: tc.exclude}); err != nil {
+				t.Errorf("test CopyDir error => %v", err)
+				return
+			}
+			testCopyDirFiles(t, dst, tc.expect)
+		})
+	}
+}
+
+func TestCopyDir_HardLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hard link detection is unsupported on Windows")
+	}
+	src := newTestCopyDir(t)
+	if err := os.Link(filepath.Join(src, "a.txt"), filepath.Join(src, "sub", "a.link")); err != nil {
+		t.Errorf("create hard link error => %v", err)
+		return
+	}
+	testCases := []struct {
+		name     string
+		preserve bool
+	}{
+		{"preserve hard links", true},
+		{"copy hard links", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "dst")
+			if err := CopyDir(src, dst, CopyOptions{PreserveHardLinks: tc.preserve}); err != nil {
+				t.Errorf("test CopyDir error => %v", err)
+				return
+			}
+			a, err := os.Stat(filepath.Join(dst, "a.txt"))
+			if err != nil {
+				t.Errorf("get file stat error => %v", err)
+				return
+			}
+			link, err := os.Stat(filepath.Join(dst, "sub", "a.link"))
+			if err != nil {
+				t.Errorf("get file stat error => %v", err)
+				return
+			}
+			if os.SameFile(a, link) != tc.preserve {
+				t.Errorf("test CopyDir error, expect the hard link is preserved: %v", tc.preserve)
+			}
+		})
+	}
+}
+
+func TestCopyDir_Symlink(t *testing.T) {
+	if !IsSymlinkSupported() {
+		t.Skip("the symbolic link is unsupported")
+	}
+	src := newTestCopyDir(t)
+	// the loop is ignored when following the symbolic links
+	if err := Symlink(src, filepath.Join(src, "sub", "loop")); err != nil {
+		t.Errorf("create symbolic link error => %v", err)
+		return
+	}
+	if err := Symlink("a.txt", filepath.Join(src, "a.link")); err != nil {
+		t.Errorf("create symbolic link error => %v", err)
+		return
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := CopyDir(src, dst, CopyOptions{}); err != nil {
+		t.Errorf("test CopyDir error => %v", err)
+		return
+	}
+	testCopyDirFiles(t, dst, []string{"a.link", "a.txt", "b.go", "sub", "sub/c.txt", "sub/deep", "sub/deep/d.go", "sub/loop"})
+	if target, err := Readlink(filepath.Join(dst, "a.link")); err != nil || target != "a.txt" {
+		t.Errorf("test CopyDir error, expect to copy the symbolic link to %s, but actual get %s, err=%v", "a.txt", target, err)
+	}
+
+	dst = filepath.Join(t.TempDir(), "dst")
+	if err := CopyDir(src, dst, CopyOptions{FollowSymlinks: true}); err != nil {
+		t.Errorf("test CopyDir error => %v", err)
+		return
+	}
+	testCopyDirFiles(t, dst, []string{"a.link", "a.txt", "b.go", "sub", "sub/c.txt", "sub/deep", "sub/deep/d.go"})
+	testAtomicFileContent(t, filepath.Join(dst, "a.link"), "hello gopher")
+}
+
+func TestCopyDir_ReturnError(t *testing.T) {
+	src := newTestCopyDir(t)
+	testCases := []struct {
+		name   string
+		src    string
+		dst    string
+		opts   CopyOptions
+		expect error
+	}{
+		{"not exist source", filepath.Join(src, "not_exist"), t.TempDir(), CopyOptions{}, os.ErrNotExist},
+		{"file source", filepath.Join(src, "a.txt"), t.TempDir(), CopyOptions{}, errCopyNotDir},
+		{"copy to itself", src, src, CopyOptions{}, errCopyToSub},
+		{"copy to subdirectory", src, filepath.Join(src, "sub", "dst"), CopyOptions{}, errCopyToSub},
+		{"bad pattern", src, t.TempDir(), CopyOptions{Include: []string{"["}}, path.ErrBadPattern},
+		{"existing file", src, newTestCopyDir(t), CopyOptions{}, fs.ErrExist},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := CopyDir(tc.src, tc.dst, tc.opts); !errors.Is(err, tc.expect) {
+				t.Errorf("test CopyDir error, expect to get %v, but actual get %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func newTestCopyFile(t *testing.T, name string, data string, mTime time.Time) {
+	if err := os.WriteFile(name, []byte(data), 0640); err != nil {
+		t.Fatalf("write file error => %v", err)
+	}
+	if err := os.Chtimes(name, mTime, mTime); err != nil {
+		t.Fatalf("change file time error => %v", err)
+	}
+}
+
+func newTestCopyDir(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.txt":         "hello gopher",
+		"b.go":          "package main",
+		"sub/c.txt":     "hello world",
+		"sub/deep/d.go": "package deep",
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("create directory error => %v", err)
+		}
+		newTestCopyFile(t, path, data, testCopyModTime)
+	}
+	for _, dir := range []string{"sub/deep", "sub", "."} {
+		if err := os.Chtimes(filepath.Join(root, dir), testCopyModTime, testCopyModTime); err != nil {
+			t.Fatalf("change directory time error => %v", err)
+		}
+	}
+	return root
+}
+
+func testCopyDirFiles(t *testing.T, dir string, expect []string) {
+	var actual []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		actual = append(actual, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Errorf("walk directory error => %v", err)
+		return
+	}
+	sort.Strings(actual)
+	if len(actual) != len(expect) {
+		t.Errorf("expect to get the files %v, but actual get %v", expect, actual)
+		return
+	}
+	for i := range expect {
+		if actual[i] != expect[i] {
+			t.Errorf("expect to get the files %v, but actual get %v", expect, actual)
+			return
+		}
+	}
+}
+
+func testCopyMetadata(t *testing.T, src string, dst string) {
+	srcStat, err := os.Stat(src)
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+	dstStat, err := os.Stat(dst)
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+	if srcStat.Mode() != dstStat.Mode() {
+		t.Errorf("expect to preserve the mode %v, but actual get %v", srcStat.Mode(), dstStat.Mode())
+	}
+	if !srcStat.ModTime().Equal(dstStat.ModTime()) {
+		t.Errorf("expect to preserve the modify time %v, but actual get %v", srcStat.ModTime(), dstStat.ModTime())
+	}
+}
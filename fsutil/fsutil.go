@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -25,6 +26,9 @@ type StatFunc func(name string) (fs.FileInfo, error)
 // GetFileTimeFunc the function prototype of GetFileTime
 type GetFileTimeFunc func(path string) (cTime time.Time, aTime time.Time, mTime time.Time, err error)
 
+// SetFileTimeFunc the function prototype of SetFileTime
+type SetFileTimeFunc func(path string, aTime time.Time, mTime time.Time) error
+
 // IsDirFunc the function prototype of IsDir
 type IsDirFunc func(path string) (bool, error)
 
@@ -78,6 +82,26 @@ func GetFileTime(path string) (cTime time.Time, aTime time.Time, mTime time.Time
 	return GetFileTimeBySys(stat.Sys())
 }
 
+// SetFileTime change the last access time and last modify time of the path in nanosecond precision, the symbolic link
+// is followed. A zero time.Time value will leave the corresponding file time unchanged
+func SetFileTime(path string, aTime time.Time, mTime time.Time) error {
+	return os.Chtimes(path, aTime, mTime)
+}
+
+// SetSymlinkFileTime is like SetFileTime, but changes the times of the symbolic link itself instead of its target
+func SetSymlinkFileTime(path string, aTime time.Time, mTime time.Time) error {
+	return setSymlinkFileTime(path, aTime, mTime)
+}
+
+// GetFileInode get the inode number of the path, always returns zero on Windows
+func GetFileInode(path string) (inode uint64, err error) {
+	stat, err := os.Lstat(path)
+	if err != nil {
+		return
+	}
+	return GetFileInodeBySys(stat.Sys())
+}
+
 // IsSub whether it is a subdirectory of the parent
 func IsSub(parent, child string) (bool, error) {
 	pAbs, err := abs(parent)
@@ -150,3 +174,54 @@ func Readlink(name string) (string, error) {
 func SymlinkText(realPath string) string {
 	return fmt.Sprintf("# symlink\n%s", realPath)
 }
+
+// MatchAny whether the slash-separated relative path or its base name matches any of the glob patterns like path.Match,
+// the malformed patterns never match
+func MatchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fileID the unique id of the file in the system
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// chownLike change the owner of the file to the owner of the fi, ignore the permission error if the current user
+// is not allowed to change the owner
+func chownLike(f *os.File, fi fs.FileInfo) error {
+	uid, gid, ok := getFileOwnerBySys(fi.Sys())
+	if !ok {
+		return nil
+	}
+	return ignorePermission(f.Chown(uid, gid))
+}
+
+// lchownLike is like chownLike, but changes the owner of the symbolic link itself
+func lchownLike(name string, fi fs.FileInfo) error {
+	uid, gid, ok := getFileOwnerBySys(fi.Sys())
+	if !ok {
+		return nil
+	}
+	return ignorePermission(os.Lchown(name, uid, gid))
+}
+
+func ignorePermission(err error) error {
+	if errors.Is(err, fs.ErrPermission) {
+		return nil
+	}
+	return err
+}
+
+// chmodBits returns the bits of the mode that can be changed by the chmod
+func chmodBits(mode fs.FileMode) fs.FileMode {
+	return mode & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+}
@@ -5,6 +5,12 @@ import (
 	"time"
 )
 
+const (
+	// utimeOmit the special nanoseconds of the utimensat to leave the time unchanged,
+	// it is the UTIME_OMIT in the sys/stat.h that is missing in the golang.org/x/sys/unix on macOS
+	utimeOmit = -2
+)
+
 // GetFileTimeBySys get the creation time, last access time, last modify time of the FileInfo.Sys()
 func GetFileTimeBySys(sys any) (cTime time.Time, aTime time.Time, mTime time.Time, err error) {
 	if sys != nil {
@@ -3,6 +3,13 @@ package fsutil
 import (
 	"syscall"
 	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// utimeOmit the special nanoseconds of the utimensat to leave the time unchanged
+	utimeOmit = unix.UTIME_OMIT
 )
 
 // GetFileTimeBySys get the creation time, last access time, last modify time of the FileInfo.Sys()
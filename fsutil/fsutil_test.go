@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/no-src/nsgo/osutil"
 )
@@ -65,6 +66,173 @@ func TestGetFileTimeBySys_ReturnError(t *testing.T) {
 	}
 }
 
+func TestSetFileTime(t *testing.T) {
+	aTime := time.Date(2020, 1, 2, 3, 4, 5, 123456700, time.UTC)
+	mTime := time.Date(2021, 6, 7, 8, 9, 10, 987654300, time.UTC)
+	testCases := []struct {
+		name        string
+		aTime       time.Time
+		mTime       time.Time
+		expectATime time.Time
+		expectMTime time.Time
+	}{
+		{"set both", aTime, mTime, aTime, mTime},
+		{"zero access time", time.Time{}, mTime.Add(time.Second), aTime, mTime.Add(time.Second)},
+		{"zero modify time", aTime.Add(time.Second), time.Time{}, aTime.Add(time.Second), mTime.Add(time.Second)},
+	}
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("set file time"), 0644); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	var setFileTime SetFileTimeFunc = SetFileTime
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := setFileTime(path, tc.aTime, tc.mTime); err != nil {
+				t.Errorf("set file time error %s => %v", path, err)
+				return
+			}
+			_, actualATime, actualMTime, err := GetFileTime(path)
+			if err != nil {
+				t.Errorf("get file time error %s => %v", path, err)
+				return
+			}
+			if !actualATime.Equal(tc.expectATime) || !actualMTime.Equal(tc.expectMTime) {
+				t.Errorf("set file time error, expect to get atime=%v mtime=%v, but actual get atime=%v mtime=%v",
+					tc.expectATime, tc.expectMTime, actualATime, actualMTime)
+			}
+		})
+	}
+}
+
+func TestSetFileTime_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   SetFileTimeFunc
+	}{
+		{"SetFileTime", SetFileTime},
+		{"SetSymlinkFileTime", SetSymlinkFileTime},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.fn(testNotFoundFilePath, time.Now(), time.Now())
+			if !os.IsNotExist(err) {
+				t.Errorf("set file time error, expect to get is not exist error, but actual get %v", err)
+			}
+		})
+	}
+}
+
+func TestSetSymlinkFileTime(t *testing.T) {
+	if !IsSymlinkSupported() {
+		t.Skip("symbolic link is unsupported")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+	if err := os.WriteFile(target, []byte("set symlink file time"), 0644); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	targetMTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := SetFileTime(target, targetMTime, targetMTime); err != nil {
+		t.Errorf("set file time error => %v", err)
+		return
+	}
+	if err := Symlink(target, link); err != nil {
+		t.Errorf("create symlink error => %v", err)
+		return
+	}
+
+	aTime := time.Date(2021, 6, 7, 8, 9, 10, 123456700, time.UTC)
+	mTime := time.Date(2022, 11, 12, 13, 14, 15, 987654300, time.UTC)
+	newATime := aTime.Add(time.Hour)
+	newMTime := mTime.Add(time.Hour)
+	testCases := []struct {
+		name        string
+		aTime       time.Time
+		mTime       time.Time
+		expectATime time.Time
+		expectMTime time.Time
+	}{
+		{"set both", aTime, mTime, aTime, mTime},
+		{"zero modify time", newATime, time.Time{}, newATime, mTime},
+		{"zero access time", time.Time{}, newMTime, newATime, newMTime},
+		{"zero both", time.Time{}, time.Time{}, newATime, newMTime},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := SetSymlinkFileTime(link, tc.aTime, tc.mTime); err != nil {
+				t.Errorf("set symlink file time error %s => %v", link, err)
+				return
+			}
+			_, actualATime, actualMTime, err := GetFileTime(link)
+			if err != nil {
+				t.Errorf("get file time error %s => %v", link, err)
+				return
+			}
+			if !actualATime.Equal(tc.expectATime) || !actualMTime.Equal(tc.expectMTime) {
+				t.Errorf("set symlink file time error, expect to get atime=%v mtime=%v, but actual get atime=%v mtime=%v", tc.expectATime, tc.expectMTime, actualATime, actualMTime)
+				return
+			}
+			_, _, actualTargetMTime, err := GetFileTime(target)
+			if err != nil {
+				t.Errorf("get file time error %s => %v", target, err)
+				return
+			}
+			if !actualTargetMTime.Equal(targetMTime) {
+				t.Errorf("set symlink file time error, expect the target is unchanged with mtime=%v, but actual get %v", targetMTime, actualTargetMTime)
+			}
+		})
+	}
+}
+
+func TestGetFileInode(t *testing.T) {
+	testCases := []struct {
+		path string
+	}{
+		{testExistFilePath},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			inode, err := GetFileInode(tc.path)
+			if err != nil {
+				t.Errorf("get file inode error %s => %v", tc.path, err)
+				return
+			}
+			if inode == 0 && !osutil.IsWindows() {
+				t.Errorf("get file inode error, expect to get a non-zero inode => %s", tc.path)
+			}
+		})
+	}
+}
+
+func TestGetFileInode_ReturnError(t *testing.T) {
+	testCases := []struct {
+		path string
+	}{
+		{testNotFoundFilePath},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			if _, err := GetFileInode(tc.path); err == nil {
+				t.Errorf("get file inode error, expect to get an error but get nil => %s", tc.path)
+			}
+		})
+	}
+}
+
+func TestGetFileInodeBySys_ReturnError(t *testing.T) {
+	if _, err := GetFileInodeBySys(nil); err == nil {
+		t.Errorf("test GetFileInodeBySys expect to get an error but get nil")
+	}
+}
+
 func TestFileExist(t *testing.T) {
 	testCases := []struct {
 		path   string
@@ -454,6 +622,30 @@ func TestSymlinkText(t *testing.T) {
 	}
 }
 
+func TestMatchAny(t *testing.T) {
+	testCases := []struct {
+		name     string
+		patterns []string
+		rel      string
+		expect   bool
+	}{
+		{"match the relative path", []string{"sub/*.txt"}, "sub/a.txt", true},
+		{"match the base name", []string{"*.txt"}, "sub/a.txt", true},
+		{"match any pattern", []string{"*.go", "a.*"}, "sub/a.txt", true},
+		{"not match", []string{"*.go", "b.*"}, "sub/a.txt", false},
+		{"without pattern", nil, "sub/a.txt", false},
+		{"malformed pattern", []string{"["}, "[", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := MatchAny(tc.patterns, tc.rel); actual != tc.expect {
+				t.Errorf("test MatchAny error, expect get %v, but actual get %v", tc.expect, actual)
+			}
+		})
+	}
+}
+
 func isNotExistAlwaysFalseMock(err error) bool {
 	return false
 }
@@ -0,0 +1,74 @@
+//go:build unix
+
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// GetFileInodeBySys get the inode number of the FileInfo.Sys()
+func GetFileInodeBySys(sys any) (inode uint64, err error) {
+	if sys != nil {
+		attr := sys.(*syscall.Stat_t)
+		if attr != nil {
+			inode = attr.Ino
+		}
+	} else {
+		err = errFileSysInfoIsNil
+	}
+	return
+}
+
+// getFileOwnerBySys get the user id and group id of the owner from the FileInfo.Sys()
+func getFileOwnerBySys(sys any) (uid int, gid int, ok bool) {
+	attr, ok := sys.(*syscall.Stat_t)
+	if !ok || attr == nil {
+		return 0, 0, false
+	}
+	return int(attr.Uid), int(attr.Gid), true
+}
+
+// getHardLinkIDBySys get the unique id of the file from the FileInfo.Sys() if it has multiple hard links
+func getHardLinkIDBySys(sys any) (id fileID, ok bool) {
+	attr, ok := sys.(*syscall.Stat_t)
+	if !ok || attr == nil || attr.Nlink <= 1 {
+		return id, false
+	}
+	return fileID{dev: uint64(attr.Dev), ino: attr.Ino}, true
+}
+
+// setSymlinkFileTime change the times of the symbolic link itself with the utimensat and AT_SYMLINK_NOFOLLOW,
+// the zero time is passed as the UTIME_OMIT to leave it unchanged in the same system call
+func setSymlinkFileTime(path string, aTime time.Time, mTime time.Time) error {
+	ts := []unix.Timespec{
+		toTimespec(aTime),
+		toTimespec(mTime),
+	}
+	if err := unix.UtimesNanoAt(unix.AT_FDCWD, path, ts, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return &fs.PathError{Op: "lchtimes", Path: path, Err: err}
+	}
+	return nil
+}
+
+// toTimespec convert the t to the unix.Timespec, or the UTIME_OMIT if the t is zero
+func toTimespec(t time.Time) unix.Timespec {
+	if t.IsZero() {
+		return unix.Timespec{Nsec: utimeOmit}
+	}
+	return unix.NsecToTimespec(t.UnixNano())
+}
+
+// syncDir fsync the directory to persist the changes of its entries, like creating and renaming the files
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
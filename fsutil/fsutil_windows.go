@@ -1,6 +1,7 @@
 package fsutil
 
 import (
+	"io/fs"
 	"syscall"
 	"time"
 )
@@ -19,3 +20,55 @@ func GetFileTimeBySys(sys any) (cTime time.Time, aTime time.Time, mTime time.Tim
 	}
 	return
 }
+
+// GetFileInodeBySys get the inode number of the FileInfo.Sys(), the inode is unsupported on Windows and always returns zero
+func GetFileInodeBySys(sys any) (inode uint64, err error) {
+	if sys == nil {
+		err = errFileSysInfoIsNil
+	}
+	return
+}
+
+// getFileOwnerBySys the owner of the file is unsupported on Windows, always returns false
+func getFileOwnerBySys(sys any) (uid int, gid int, ok bool) {
+	return 0, 0, false
+}
+
+// getHardLinkIDBySys the FileInfo.Sys() doesn't contain the count of hard links on Windows, always returns false
+func getHardLinkIDBySys(sys any) (id fileID, ok bool) {
+	return id, false
+}
+
+// setSymlinkFileTime change the times of the symbolic link itself by opening it with the FILE_FLAG_OPEN_REPARSE_POINT,
+// the zero time is passed as nil to leave it unchanged
+func setSymlinkFileTime(path string, aTime time.Time, mTime time.Time) error {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return &fs.PathError{Op: "lchtimes", Path: path, Err: err}
+	}
+	h, err := syscall.CreateFile(pathPtr, syscall.FILE_WRITE_ATTRIBUTES,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE, nil, syscall.OPEN_EXISTING,
+		syscall.FILE_FLAG_OPEN_REPARSE_POINT|syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return &fs.PathError{Op: "lchtimes", Path: path, Err: err}
+	}
+	defer syscall.CloseHandle(h)
+	if err = syscall.SetFileTime(h, nil, toFiletime(aTime), toFiletime(mTime)); err != nil {
+		return &fs.PathError{Op: "lchtimes", Path: path, Err: err}
+	}
+	return nil
+}
+
+// toFiletime convert the t to the syscall.Filetime, or nil to leave the time unchanged if the t is zero
+func toFiletime(t time.Time) *syscall.Filetime {
+	if t.IsZero() {
+		return nil
+	}
+	ft := syscall.NsecToFiletime(t.UnixNano())
+	return &ft
+}
+
+// syncDir the directory can't be opened to fsync on Windows, do nothing
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,301 @@
+package hashutil
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/no-src/nsgo/fsutil"
+)
+
+const (
+	// defaultHashCacheCapacity the default max count of the entries in the HashCache
+	defaultHashCacheCapacity = 10000
+)
+
+// HashCache the persistent cache of the checkpoint HashValues of the files, the entry is valid only if the path, size,
+// mtime and inode of the file are unchanged, the inode is ignored on Windows. The entries are stored in an append-only
+// log file of JSON lines, the least recently used entries are evicted when exceeding the capacity, and the log file is
+// compacted when it contains too many stale records. It is safe for concurrent use and can be shared by different
+// Hash instances, see WithCache
+type HashCache struct {
+	mu       sync.Mutex
+	path     string
+	f        *os.File
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List
+	// records the count of the records in the log file
+	records int
+}
+
+// hashCacheRecord a record of the log file, the entry is removed if the Deleted is true
+type hashCacheRecord struct {
+	Path            string     `json:"path"`
+	Fingerprint     string     `json:"fingerprint"`
+	ChunkSize       int64      `json:"chunk_size"`
+	CheckpointCount int        `json:"checkpoint_count"`
+	Size            int64      `json:"size,omitempty"`
+	ModTime         int64      `json:"mod_time,omitempty"`
+	Inode           uint64     `json:"inode,omitempty"`
+	HashValues      HashValues `json:"hash_values,omitempty"`
+	Deleted         bool       `json:"deleted,omitempty"`
+}
+
+func (r *hashCacheRecord) key() string {
+	return r.Path + "\x00" + r.Fingerprint + "\x00" + strconv.FormatInt(r.ChunkSize, 10) + "\x00" + strconv.Itoa(r.CheckpointCount)
+}
+
+// NewHashCache open or create the HashCache with the log file, the capacity is the max count of the entries,
+// zero or negative means the default capacity
+func NewHashCache(path string, capacity int) (*HashCache, error) {
+	if len(path) == 0 {
+		return nil, errEmptyPath
+	}
+	if capacity <= 0 {
+		capacity = defaultHashCacheCapacity
+	}
+	c := &HashCache{
+		path:     path,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	c.f = f
+	return c, nil
+}
+
+// load replay the records of the log file, the broken records are ignored, e.g. the last line that is partially written
+func (c *HashCache) load() error {
+	f, err := os.Open(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		c.records++
+		var r hashCacheRecord
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			continue
+		}
+		if r.Deleted {
+			c.remove(r.key())
+		} else {
+			c.set(&r)
+		}
+	}
+	return scanner.Err()
+}
+
+// Len returns the count of the entries
+func (c *HashCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Invalidate remove all the entries of the file
+func (c *HashCache) Invalidate(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if r := e.Value.(*hashCacheRecord); r.Path == path {
+			c.remove(r.key())
+			if err = c.append(&hashCacheRecord{Path: r.Path, Fingerprint: r.Fingerprint, ChunkSize: r.ChunkSize, CheckpointCount: r.CheckpointCount, Deleted: true}); err != nil {
+				return err
+			}
+		}
+		e = next
+	}
+	return nil
+}
+
+// Compact rewrite the log file with the current entries only
+func (c *HashCache) Compact() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.compact()
+}
+
+// Close close the log file
+func (c *HashCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.f.Close()
+}
+
+// checkpointsHash returns the cached HashValues of the file if it is unchanged,
+// otherwise calculate the HashValues with the dh and update the cache
+func (c *HashCache) checkpointsHash(ctx context.Context, dh *defaultHash, path string, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	if len(path) == 0 {
+		return nil, errEmptyPath
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &hashCacheRecord{
+		Path:            absPath,
+		Fingerprint:     dh.fingerprint(),
+		ChunkSize:       chunkSize,
+		CheckpointCount: checkpointCount,
+	}
+	if err = r.stat(); err != nil {
+		return nil, err
+	}
+	key := r.key()
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		cached := e.Value.(*hashCacheRecord)
+		if cached.Size == r.Size && cached.ModTime == r.ModTime && cached.Inode == r.Inode {
+			c.lru.MoveToBack(e)
+			c.mu.Unlock()
+			return cached.HashValues.clone(), nil
+		}
+	}
+	c.mu.Unlock()
+
+	hvs, err = dh.checkpointsHashFromFileName(ctx, path, chunkSize, checkpointCount)
+	if err != nil {
+		return nil, err
+	}
+	// do not cache the HashValues if the file is changed during hashing
+	after := *r
+	if after.stat() != nil || after.Size != r.Size || after.ModTime != r.ModTime || after.Inode != r.Inode {
+		return hvs, nil
+	}
+	r.HashValues = hvs.clone()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(r)
+	// the cache is best-effort, the entry is kept in memory and the HashValues are returned even if failed to persist it
+	c.append(r)
+	return hvs, nil
+}
+
+// stat fill the size, mtime and inode of the file
+func (r *hashCacheRecord) stat() error {
+	stat, err := os.Stat(r.Path)
+	if err != nil {
+		return err
+	}
+	_, _, mTime, err := fsutil.GetFileTimeBySys(stat.Sys())
+	if err != nil {
+		return err
+	}
+	inode, err := fsutil.GetFileInodeBySys(stat.Sys())
+	if err != nil {
+		return err
+	}
+	r.Size = stat.Size()
+	r.ModTime = mTime.UnixNano()
+	r.Inode = inode
+	return nil
+}
+
+// set add or update the entry and evict the least recently used entries, the caller must hold the lock
+func (c *HashCache) set(r *hashCacheRecord) {
+	key := r.key()
+	if e, ok := c.entries[key]; ok {
+		e.Value = r
+		c.lru.MoveToBack(e)
+		return
+	}
+	c.entries[key] = c.lru.PushBack(r)
+	for c.lru.Len() > c.capacity {
+		c.remove(c.lru.Front().Value.(*hashCacheRecord).key())
+	}
+}
+
+// remove the entry, the caller must hold the lock
+func (c *HashCache) remove(key string) {
+	if e, ok := c.entries[key]; ok {
+		c.lru.Remove(e)
+		delete(c.entries, key)
+	}
+}
+
+// append the record to the log file, and compact the log file if it contains too many stale records,
+// the caller must hold the lock
+func (c *HashCache) append(r *hashCacheRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err = c.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	c.records++
+	if c.records > 2*c.capacity {
+		return c.compact()
+	}
+	return nil
+}
+
+// compact rewrite the log file with the current entries in the order from the least recently used,
+// the caller must hold the lock
+func (c *HashCache) compact() error {
+	tmp := c.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for e := c.lru.Front(); e != nil && err == nil; e = e.Next() {
+		var data []byte
+		if data, err = json.Marshal(e.Value); err == nil {
+			_, err = w.Write(append(data, '\n'))
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// close the log file before renaming, it is required on Windows
+	c.f.Close()
+	if err = os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+	} else {
+		c.records = c.lru.Len()
+	}
+	var openErr error
+	c.f, openErr = os.OpenFile(c.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err == nil {
+		err = openErr
+	}
+	return err
+}
+
+// fingerprint identify the configuration of the hash that affects the hash values, include the algorithm and the encoding.
+// The keyed hash is never cached, so nothing derived from the key is stored in the log file
+func (dh *defaultHash) fingerprint() string {
+	return dh.algorithm + ":" + strconv.Itoa(int(dh.encoding))
+}
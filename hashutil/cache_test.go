@@ -0,0 +1,360 @@
+package hashutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHashCache(t *testing.T) {
+	root := newTestManifestDir(t)
+	path := filepath.Join(root, "a.txt")
+	cache, err := NewHashCache(filepath.Join(t.TempDir(), "hash.cache"), 0)
+	if err != nil {
+		t.Errorf("test NewHashCache error => %v", err)
+		return
+	}
+	defer cache.Close()
+
+	var hashed atomic.Int64
+	h, err := NewHash(DefaultHash, WithCache(cache), WithProgress(ProgressFuncs{
+		Checkpoint: func(hv *HashValue) {
+			hashed.Add(1)
+		},
+	}))
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	expect, err := testHash.CheckpointsHashFromFileName(path, 4, 2)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+		return
+	}
+
+	// the first call calculates the HashValues, the second call hits the cache
+	for i := 0; i < 2; i++ {
+		actual, err := h.CheckpointsHashFromFileName(path, 4, 2)
+		if err != nil {
+			t.Errorf("test CheckpointsHashFromFileName with cache error => %v", err)
+			return
+		}
+		testHashValuesEqual(t, expect, actual)
+	}
+	if hashed.Load() != int64(len(expect)) {
+		t.Errorf("test HashCache error, expect to hash the file only once")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("test HashCache error, expect to get 1 entry, but actual get %d", cache.Len())
+	}
+
+	// the modified file is hashed again
+	mTime := time.Now().Add(time.Hour)
+	if err = os.WriteFile(path, []byte("HELLO GOPHER"), 0644); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	if err = os.Chtimes(path, mTime, mTime); err != nil {
+		t.Errorf("change file time error => %v", err)
+		return
+	}
+	size, hash, _, err := h.GetFileSizeAndHashCheckpoints(path, 4, 2)
+	if err != nil {
+		t.Errorf("test GetFileSizeAndHashCheckpoints with cache error => %v", err)
+		return
+	}
+	if expectHash := h.HashFromString("HELLO GOPHER"); size != 12 || hash != expectHash {
+		t.Errorf("test HashCache error, expect to get %s, but actual get %s", expectHash, hash)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("test HashCache error, expect to get 1 entry, but actual get %d", cache.Len())
+	}
+}
+
+func TestHashCache_Persist(t *testing.T) {
+	root := newTestManifestDir(t)
+	cachePath := filepath.Join(t.TempDir(), "hash.cache")
+	cache, err := NewHashCache(cachePath, 0)
+	if err != nil {
+		t.Errorf("test NewHashCache error => %v", err)
+		return
+	}
+	md5Hash, _ := NewHash(MD5Hash, WithCache(cache))
+	sha256Hash, _ := NewHash(SHA256Hash, WithCache(cache))
+	for _, name := range []string{"a.txt", "b.go"} {
+		for _, h := range []Hash{md5Hash, sha256Hash} {
+			if _, err = h.CheckpointsHashFromFileName(filepath.Join(root, name), 0, 0); err != nil {
+				t.Errorf("test CheckpointsHashFromFileName with cache error => %v", err)
+				return
+			}
+		}
+	}
+	if err = cache.Invalidate(filepath.Join(root, "b.go")); err != nil {
+		t.Errorf("test Invalidate error => %v", err)
+		return
+	}
+	if err = cache.Close(); err != nil {
+		t.Errorf("test Close error => %v", err)
+		return
+	}
+
+	// the partially written record is ignored
+	f, err := os.OpenFile(cachePath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	f.WriteString(`{"path":"broken`)
+	f.Close()
+
+	cache, err = NewHashCache(cachePath, 0)
+	if err != nil {
+		t.Errorf("test NewHashCache error => %v", err)
+		return
+	}
+	defer cache.Close()
+	if cache.Len() != 2 {
+		t.Errorf("test HashCache error, expect to load 2 entries, but actual get %d", cache.Len())
+	}
+	if err = cache.Compact(); err != nil {
+		t.Errorf("test Compact error => %v", err)
+		return
+	}
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+		t.Errorf("test Compact error, expect to get 2 records, but actual get %d", lines)
+	}
+}
+
+func TestHashCache_Evict(t *testing.T) {
+	root := newTestManifestDir(t)
+	cachePath := filepath.Join(t.TempDir(), "hash.cache")
+	cache, err := NewHashCache(cachePath, 2)
+	if err != nil {
+		t.Errorf("test NewHashCache error => %v", err)
+		return
+	}
+	defer cache.Close()
+	h, _ := NewHash(DefaultHash, WithCache(cache))
+	names := []string{"a.txt", "b.go", "a.txt", "sub/c.txt", "sub/deep/d.go", "a.txt"}
+	for _, name := range names {
+		if _, err = h.CheckpointsHashFromFileName(filepath.Join(root, filepath.FromSlash(name)), 0, 0); err != nil {
+			t.Errorf("test CheckpointsHashFromFileName with cache error => %v", err)
+			return
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("test HashCache error, expect to get 2 entries, but actual get %d", cache.Len())
+	}
+	absPath, _ := filepath.Abs(filepath.Join(root, "a.txt"))
+	if _, ok := cache.entries[(&hashCacheRecord{Path: absPath, Fingerprint: testHash.fingerprint()}).key()]; !ok {
+		t.Errorf("test HashCache error, expect the recently used entry is not evicted")
+	}
+	// the log file is compacted when it contains too many records
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines > 2*2 {
+		t.Errorf("test HashCache error, expect the log file is compacted, but actual get %d records", lines)
+	}
+}
+
+func TestHashCache_Concurrent(t *testing.T) {
+	root := newTestManifestDir(t)
+	cache, err := NewHashCache(filepath.Join(t.TempDir(), "hash.cache"), 3)
+	if err != nil {
+		t.Errorf("test NewHashCache error => %v", err)
+		return
+	}
+	defer cache.Close()
+	h, _ := NewHash(DefaultHash, WithCache(cache))
+	names := []string{"a.txt", "b.go", "sub/c.txt", "sub/deep/d.go"}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			path := filepath.Join(root, filepath.FromSlash(name))
+			expect, err := testHash.HashFromFileName(path)
+			if err != nil {
+				t.Errorf("test HashFromFileName error => %v", err)
+				return
+			}
+			hvs, err := h.CheckpointsHashFromFileName(path, 0, 0)
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFileName with cache error => %v", err)
+				return
+			}
+			if hvs.Last().Hash != expect {
+				t.Errorf("test HashCache error, expect to get %s, but actual get %s", expect, hvs.Last().Hash)
+			}
+			cache.Invalidate(path)
+		}(names[i%len(names)])
+	}
+	wg.Wait()
+}
+
+func TestHashCache_Keyed(t *testing.T) {
+	root := newTestManifestDir(t)
+	path := filepath.Join(root, "a.txt")
+	cache, err := NewHashCache(filepath.Join(t.TempDir(), "hash.cache"), 0)
+	if err != nil {
+		t.Errorf("test NewHashCache error => %v", err)
+		return
+	}
+	defer cache.Close()
+
+	testCases := []struct {
+		name string
+		h    func(opts ...Option) (Hash, error)
+	}{
+		{"hmac", func(opts ...Option) (Hash, error) {
+			return NewHMAC(SHA256Hash, []byte("secret"), opts...)
+		}},
+		{"keyed blake2b", func(opts ...Option) (Hash, error) {
+			return NewKeyedHash(BLAKE2b256Hash, []byte("secret"), opts...)
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := tc.h()
+			if err != nil {
+				t.Errorf("init hash component error => %v", err)
+				return
+			}
+			cachedHash, err := tc.h(WithCache(cache))
+			if err != nil {
+				t.Errorf("init hash component error => %v", err)
+				return
+			}
+			expect, err := h.CheckpointsHashFromFileName(path, 4, 2)
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+				return
+			}
+			actual, err := cachedHash.CheckpointsHashFromFileName(path, 4, 2)
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFileName with cache error => %v", err)
+				return
+			}
+			testHashValuesEqual(t, expect, actual)
+			if cache.Len() != 0 {
+				t.Errorf("test HashCache error, expect the keyed hash values are not cached, but actual get %d entries", cache.Len())
+			}
+		})
+	}
+}
+
+func TestHashCache_KeyedPrefix(t *testing.T) {
+	root := newTestManifestDir(t)
+	path := filepath.Join(root, "a.txt")
+	cache, err := NewHashCache(filepath.Join(t.TempDir(), "hash.cache"), 0)
+	if err != nil {
+		t.Errorf("test NewHashCache error => %v", err)
+		return
+	}
+	defer cache.Close()
+
+	// the custom algorithm is not keyed even if the name has the keyed prefix
+	name := HMACPrefix + "custom-sha256"
+	if err = RegisterHash(name, sha256.New); err != nil {
+		t.Errorf("register hash error => %v", err)
+		return
+	}
+	defer Unregister(name)
+
+	h, err := NewHash(name, WithCache(cache))
+	if err != nil {
+		t.Errorf("init hash with the custom algorithm error => %v", err)
+		return
+	}
+	if _, err = h.CheckpointsHashFromFileName(path, 4, 2); err != nil {
+		t.Errorf("test CheckpointsHashFromFileName with cache error => %v", err)
+		return
+	}
+	if cache.Len() != 1 {
+		t.Errorf("test HashCache error, expect to get 1 entry, but actual get %d", cache.Len())
+	}
+}
+
+func TestHashCache_WriteError(t *testing.T) {
+	root := newTestManifestDir(t)
+	path := filepath.Join(root, "a.txt")
+	cache, err := NewHashCache(filepath.Join(t.TempDir(), "hash.cache"), 0)
+	if err != nil {
+		t.Errorf("test NewHashCache error => %v", err)
+		return
+	}
+	// the log file can't be written after closing
+	cache.Close()
+
+	h, _ := NewHash(DefaultHash, WithCache(cache))
+	expect, err := testHash.CheckpointsHashFromFileName(path, 4, 2)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+		return
+	}
+	actual, err := h.CheckpointsHashFromFileName(path, 4, 2)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromFileName with cache error, expect the cache write error is ignored, but actual get %v", err)
+		return
+	}
+	testHashValuesEqual(t, expect, actual)
+}
+
+func TestNewHashCache_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{"empty path", ""},
+		{"directory", t.TempDir()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewHashCache(tc.path, 0)
+			if err == nil {
+				t.Errorf("test NewHashCache error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestHashCache_ReturnError(t *testing.T) {
+	cache, err := NewHashCache(filepath.Join(t.TempDir(), "hash.cache"), 0)
+	if err != nil {
+		t.Errorf("test NewHashCache error => %v", err)
+		return
+	}
+	defer cache.Close()
+	h, _ := NewHash(DefaultHash, WithCache(cache))
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{"empty path", ""},
+		{"not exist file", notExistFilePath},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := h.CheckpointsHashFromFileName(tc.path, 0, 0)
+			if err == nil {
+				t.Errorf("test CheckpointsHashFromFileName with cache error, expect to get an error but get nil")
+			}
+		})
+	}
+}
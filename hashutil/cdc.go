@@ -0,0 +1,189 @@
+package hashutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+const (
+	// DefaultCDCMinSize the default min size of the content-defined chunk
+	DefaultCDCMinSize = 2 * 1024
+	// DefaultCDCAvgSize the default expected average size of the content-defined chunk
+	DefaultCDCAvgSize = 8 * 1024
+	// DefaultCDCMaxSize the default max size of the content-defined chunk
+	DefaultCDCMaxSize = 64 * 1024
+
+	// minCDCSize the lower limit of the min size, avoid the tiny chunks
+	minCDCSize = 64
+)
+
+var (
+	// gearTable the random numbers for the gear hash, generated by the splitmix64 with a fixed seed,
+	// so the chunk boundaries are stable across the processes
+	gearTable [256]uint64
+
+	errCDCSizeInvalid = errors.New("the chunk sizes must satisfy 64 <= min <= avg <= max")
+)
+
+// CDCOptions the options of the content-defined chunking
+type CDCOptions struct {
+	// MinSize the min size of the chunk, except the last one
+	MinSize int
+	// AvgSize the expected average size of the chunk
+	AvgSize int
+	// MaxSize the max size of the chunk
+	MaxSize int
+}
+
+// DefaultCDCOptions returns the default options of the content-defined chunking
+func DefaultCDCOptions() CDCOptions {
+	return CDCOptions{
+		MinSize: DefaultCDCMinSize,
+		AvgSize: DefaultCDCAvgSize,
+		MaxSize: DefaultCDCMaxSize,
+	}
+}
+
+// Chunker split the stream into variable-size chunks with the FastCDC algorithm,
+// the chunk boundaries are determined by the content, so they don't shift after the data is inserted or deleted
+type Chunker struct {
+	r      io.Reader
+	opts   CDCOptions
+	maskS  uint64
+	maskL  uint64
+	buf    []byte
+	start  int
+	end    int
+	offset int64
+	isEOF  bool
+}
+
+// NewChunker returns an instance of Chunker that reads the data from the r
+func NewChunker(r io.Reader, opts CDCOptions) (*Chunker, error) {
+	if r == nil {
+		return nil, errNilFile
+	}
+	if opts.MinSize < minCDCSize || opts.MinSize > opts.AvgSize || opts.AvgSize > opts.MaxSize {
+		return nil, errCDCSizeInvalid
+	}
+	// normalized chunking, use the harder mask before the average size and the easier mask after it
+	avgBits := bits.Len(uint(opts.AvgSize)) - 1
+	return &Chunker{
+		r:     r,
+		opts:  opts,
+		maskS: highBitsMask(avgBits + 2),
+		maskL: highBitsMask(avgBits - 2),
+		buf:   make([]byte, opts.MaxSize*2),
+	}, nil
+}
+
+// highBitsMask returns the mask with the n high bits set, the high bits of the gear hash
+// are affected by more bytes than the low bits
+func highBitsMask(n int) uint64 {
+	n = max(n, 1)
+	return ^uint64(0) << (64 - n)
+}
+
+// Next returns the offset and the data of the next chunk, the data is only valid until the next call.
+// Returns the io.EOF when there is no more chunk
+func (c *Chunker) Next() (offset int64, data []byte, err error) {
+	if c.end-c.start < c.opts.MaxSize && !c.isEOF {
+		c.end = copy(c.buf, c.buf[c.start:c.end])
+		c.start = 0
+		for c.end < len(c.buf) && !c.isEOF {
+			n, err := c.r.Read(c.buf[c.end:])
+			c.end += n
+			if err == io.EOF {
+				c.isEOF = true
+			} else if err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	if c.start == c.end {
+		return 0, nil, io.EOF
+	}
+	n := c.cut(c.buf[c.start:c.end])
+	data = c.buf[c.start : c.start+n]
+	offset = c.offset
+	c.start += n
+	c.offset += int64(n)
+	return offset, data, nil
+}
+
+// cut returns the length of the next chunk in the src
+func (c *Chunker) cut(src []byte) int {
+	n := len(src)
+	if n <= c.opts.MinSize {
+		return n
+	}
+	n = min(n, c.opts.MaxSize)
+	normal := min(n, c.opts.AvgSize)
+	var fp uint64
+	i := c.opts.MinSize
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gearTable[src[i]]
+		if fp&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gearTable[src[i]]
+		if fp&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// ContentDefinedChunks split the data into variable-size chunks with the FastCDC algorithm,
+// and returns the offset, length and hash value of every chunk that is calculated by the h
+func ContentDefinedChunks(h Hash, r io.Reader, opts CDCOptions) (chunks ChunkHashes, err error) {
+	return ContentDefinedChunksContext(context.Background(), h, r, opts)
+}
+
+// ContentDefinedChunksContext is like ContentDefinedChunks but returns the ctx.Err() when the ctx is done
+func ContentDefinedChunksContext(ctx context.Context, h Hash, r io.Reader, opts CDCOptions) (chunks ChunkHashes, err error) {
+	if h == nil {
+		return nil, errNilHash
+	}
+	if err = checkTextEncoding(h); err != nil {
+		return nil, err
+	}
+	c, err := NewChunker(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		offset, data, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		hash := h.Hash(data)
+		if err = checkTextHash(hash); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, NewChunkHash(offset, int64(len(data)), hash))
+	}
+	return chunks, nil
+}
+
+func init() {
+	seed := uint64(0x6e73676f63646321)
+	for i := range gearTable {
+		// splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		gearTable[i] = z ^ (z >> 31)
+	}
+}
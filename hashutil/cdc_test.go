@@ -0,0 +1,118 @@
+package hashutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestContentDefinedChunks(t *testing.T) {
+	data := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	opts := DefaultCDCOptions()
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{"random data", data},
+		{"zero data", make([]byte, 200*1024)},
+		{"smaller than min size", data[:100]},
+		{"empty data", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks, err := ContentDefinedChunks(testHash, bytes.NewReader(tc.data), opts)
+			if err != nil {
+				t.Errorf("test ContentDefinedChunks error => %v", err)
+				return
+			}
+			var offset int64
+			for i, chunk := range chunks {
+				if chunk.Offset != offset {
+					t.Errorf("test ContentDefinedChunks error, expect the chunk offset %d, but actual get %d", offset, chunk.Offset)
+					return
+				}
+				if chunk.Length > int64(opts.MaxSize) || (chunk.Length < int64(opts.MinSize) && i != len(chunks)-1) {
+					t.Errorf("test ContentDefinedChunks error, the chunk size %d is out of range", chunk.Length)
+				}
+				if expect := testHash.Hash(tc.data[chunk.Offset : chunk.Offset+chunk.Length]); chunk.Hash != expect {
+					t.Errorf("test ContentDefinedChunks error, expect the chunk hash %s, but actual get %s", expect, chunk.Hash)
+				}
+				offset += chunk.Length
+			}
+			if offset != int64(len(tc.data)) {
+				t.Errorf("test ContentDefinedChunks error, expect the total length %d, but actual get %d", len(tc.data), offset)
+			}
+		})
+	}
+}
+
+func TestContentDefinedChunks_StableBoundaries(t *testing.T) {
+	data := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(2)).Read(data)
+	edited := append([]byte("hello gopher"), data...)
+	opts := DefaultCDCOptions()
+
+	chunks, err := ContentDefinedChunks(testHash, bytes.NewReader(data), opts)
+	if err != nil {
+		t.Errorf("test ContentDefinedChunks error => %v", err)
+		return
+	}
+	editedChunks, err := ContentDefinedChunks(testHash, bytes.NewReader(edited), opts)
+	if err != nil {
+		t.Errorf("test ContentDefinedChunks error => %v", err)
+		return
+	}
+
+	hashes := make(map[string]bool, len(chunks))
+	for _, chunk := range chunks {
+		hashes[chunk.Hash] = true
+	}
+	shared := 0
+	for _, chunk := range editedChunks {
+		if hashes[chunk.Hash] {
+			shared++
+		}
+	}
+	if shared < len(chunks)-2 {
+		t.Errorf("test ContentDefinedChunks error, expect most of the chunks are shared after the insertion, but only %d of %d", shared, len(chunks))
+	}
+}
+
+func TestNewChunker_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name string
+		r    io.Reader
+		opts CDCOptions
+	}{
+		{"nil reader", nil, DefaultCDCOptions()},
+		{"min size too small", strings.NewReader("hello"), CDCOptions{MinSize: 1, AvgSize: 1024, MaxSize: 4096}},
+		{"min size larger than avg size", strings.NewReader("hello"), CDCOptions{MinSize: 2048, AvgSize: 1024, MaxSize: 4096}},
+		{"avg size larger than max size", strings.NewReader("hello"), CDCOptions{MinSize: 512, AvgSize: 8192, MaxSize: 4096}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewChunker(tc.r, tc.opts); err == nil {
+				t.Errorf("test NewChunker error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestContentDefinedChunks_ReturnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ContentDefinedChunksContext(ctx, testHash, strings.NewReader("hello"), DefaultCDCOptions()); !errors.Is(err, context.Canceled) {
+		t.Errorf("test ContentDefinedChunksContext error, expect to get error %v, but actual get %v", context.Canceled, err)
+	}
+	if _, err := ContentDefinedChunks(testHash, readwrite{nil}, DefaultCDCOptions()); err == nil {
+		t.Errorf("test ContentDefinedChunks error, expect to get a read error but get nil")
+	}
+}
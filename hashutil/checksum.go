@@ -0,0 +1,324 @@
+package hashutil
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ChecksumText the GNU coreutils text mode format, like "<hash>  <path>"
+	ChecksumText ChecksumFormat = iota
+	// ChecksumBinary the GNU coreutils binary mode format, like "<hash> *<path>"
+	ChecksumBinary
+	// ChecksumTag the BSD-style tagged format, like "SHA256 (<path>) = <hash>"
+	ChecksumTag
+)
+
+const (
+	// ChecksumOK the hash value of the file matches the checksum
+	ChecksumOK ChecksumStatus = "ok"
+	// ChecksumFailed the hash value of the file does not match the checksum, the file can't be read,
+	// or the hash algorithm of the checksum is unsupported
+	ChecksumFailed ChecksumStatus = "failed"
+	// ChecksumMissing the file does not exist
+	ChecksumMissing ChecksumStatus = "missing"
+)
+
+// ChecksumFormat the line format of the checksum file
+type ChecksumFormat int
+
+// ChecksumStatus the verification status of a file in the checksum file
+type ChecksumStatus string
+
+// ChecksumEntry a line of the checksum file
+type ChecksumEntry struct {
+	// Format the line format
+	Format ChecksumFormat `json:"format"`
+	// Algorithm the hash algorithm in lower case, only the ChecksumTag format contains it
+	Algorithm string `json:"algorithm,omitempty"`
+	// Path the file path
+	Path string `json:"path"`
+	// Hash the hash value in hex
+	Hash string `json:"hash"`
+}
+
+// String returns the line of the entry without the line break, the path that contains a backslash or line break
+// is escaped the way GNU coreutils does
+func (e *ChecksumEntry) String() string {
+	name, escaped := escapeChecksumPath(e.Path)
+	var line string
+	switch e.Format {
+	case ChecksumBinary:
+		line = e.Hash + " *" + name
+	case ChecksumTag:
+		line = checksumTag(e.Algorithm) + " (" + name + ") = " + e.Hash
+	default:
+		line = e.Hash + "  " + name
+	}
+	if escaped {
+		line = `\` + line
+	}
+	return line
+}
+
+// ChecksumResult the verification result of a file in the checksum file
+type ChecksumResult struct {
+	// Path the file path in the checksum file
+	Path string `json:"path"`
+	// Algorithm the hash algorithm to verify the file
+	Algorithm string `json:"algorithm"`
+	// Status the verification status
+	Status ChecksumStatus `json:"status"`
+	// Expect the hash value in the checksum file
+	Expect string `json:"expect"`
+	// Actual the hash value of the file, empty if the file can't be read
+	Actual string `json:"actual,omitempty"`
+	// Err the error of reading the file
+	Err error `json:"-"`
+}
+
+// ChecksumReport the verification results of all the files in the checksum file and the summary
+type ChecksumReport struct {
+	// Results the verification results in the order of the checksum file
+	Results []*ChecksumResult `json:"results"`
+	// Passed the count of the ChecksumOK files
+	Passed int `json:"passed"`
+	// Failed the count of the ChecksumFailed files
+	Failed int `json:"failed"`
+	// Missing the count of the ChecksumMissing files
+	Missing int `json:"missing"`
+}
+
+// OK whether all the files are verified successfully
+func (r *ChecksumReport) OK() bool {
+	return r.Failed == 0 && r.Missing == 0
+}
+
+// WriteChecksums calculate the hash values of the files with the h and write them to the w in the checksum file
+// format, like the output of sha256sum
+func WriteChecksums(h Hash, w io.Writer, format ChecksumFormat, paths ...string) error {
+	return WriteChecksumsContext(context.Background(), h, w, format, paths...)
+}
+
+// WriteChecksumsContext is like WriteChecksums but returns the ctx.Err() when the ctx is done
+func WriteChecksumsContext(ctx context.Context, h Hash, w io.Writer, format ChecksumFormat, paths ...string) error {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		hash, err := dh.hexHashFromFileName(ctx, path)
+		if err != nil {
+			return err
+		}
+		e := &ChecksumEntry{
+			Format:    format,
+			Algorithm: dh.algorithm,
+			Path:      filepath.ToSlash(path),
+			Hash:      hash,
+		}
+		if _, err = io.WriteString(w, e.String()+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyChecksums parse the checksum file from the r and verify every listed file with the h, like sha256sum -c.
+// The relative paths are resolved against the dir, and the tagged lines are verified with their own algorithms
+func VerifyChecksums(h Hash, r io.Reader, dir string) (report *ChecksumReport, err error) {
+	return VerifyChecksumsContext(context.Background(), h, r, dir)
+}
+
+// VerifyChecksumsContext is like VerifyChecksums but returns the ctx.Err() when the ctx is done
+func VerifyChecksumsContext(ctx context.Context, h Hash, r io.Reader, dir string) (report *ChecksumReport, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ParseChecksums(r)
+	if err != nil {
+		return nil, err
+	}
+	report = &ChecksumReport{}
+	for _, e := range entries {
+		result := &ChecksumResult{
+			Path:      e.Path,
+			Algorithm: dh.algorithm,
+			Expect:    e.Hash,
+		}
+		entryHash := dh
+		if len(e.Algorithm) > 0 && e.Algorithm != dh.algorithm {
+			// the entry with the unsupported algorithm is failed, and the other entries are still verified
+			result.Algorithm = strings.ToLower(e.Algorithm)
+			entryHash, result.Err = dh.withAlgorithm(e.Algorithm)
+		}
+		if result.Err == nil {
+			result.Actual, result.Err = entryHash.hexHashFromFileName(ctx, dh.joinPath(dir, e.Path))
+		}
+		switch {
+		case errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded):
+			return nil, result.Err
+		case errors.Is(result.Err, fs.ErrNotExist):
+			result.Status = ChecksumMissing
+			report.Missing++
+		case result.Err == nil && equalHexHash(result.Actual, e.Hash):
+			result.Status = ChecksumOK
+			report.Passed++
+		default:
+			result.Status = ChecksumFailed
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// hexHashFromFileName calculate the hash value of the file in hex, the checksum file always uses the hex encoding
+// regardless of the encoding of the hash
+func (dh *defaultHash) hexHashFromFileName(ctx context.Context, path string) (string, error) {
+	f, err := dh.open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sum, err := dh.sumFromFile(ctx, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// equalHexHash whether the two hex hash values are equal regardless of the case, the values are compared in constant time
+// like matchHash
+func equalHexHash(a string, b string) bool {
+	return hmac.Equal([]byte(strings.ToLower(a)), []byte(strings.ToLower(b)))
+}
+
+// withAlgorithm returns a copy of the hash with the same options but another hash algorithm
+func (dh *defaultHash) withAlgorithm(algorithm string) (*defaultHash, error) {
+	f, err := getFactory(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	h := *dh
+	h.algorithm = strings.ToLower(algorithm)
+	h.factory = f
+	h.keyed = false
+	return &h, nil
+}
+
+// ParseChecksums parse the checksum file in the GNU coreutils text, binary or BSD-style tagged format,
+// the formats can be mixed in a file, and the empty lines are ignored
+func ParseChecksums(r io.Reader) (entries []*ChecksumEntry, err error) {
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(line) == 0 {
+			continue
+		}
+		e, ok := parseChecksumLine(line)
+		if !ok {
+			return nil, fmt.Errorf("improperly formatted checksum line => %d", lineNum)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+func parseChecksumLine(line string) (e *ChecksumEntry, ok bool) {
+	escaped := strings.HasPrefix(line, `\`)
+	if escaped {
+		line = line[1:]
+	}
+	e = &ChecksumEntry{}
+	if i := strings.IndexByte(line, ' '); i > 0 && i+2 < len(line) && isHexString(line[:i]) && (line[i+1] == ' ' || line[i+1] == '*') {
+		e.Hash = line[:i]
+		e.Path = line[i+2:]
+		e.Format = ChecksumText
+		if line[i+1] == '*' {
+			e.Format = ChecksumBinary
+		}
+	} else {
+		// TAG (path) = hash
+		start := strings.Index(line, " (")
+		end := strings.LastIndex(line, ") = ")
+		if start <= 0 || end < start+2 || !isHexString(line[end+4:]) {
+			return nil, false
+		}
+		e.Format = ChecksumTag
+		e.Algorithm = strings.ToLower(line[:start])
+		if e.Algorithm == "blake2b" {
+			e.Algorithm = BLAKE2b512Hash
+		}
+		e.Path = line[start+2 : end]
+		e.Hash = line[end+4:]
+	}
+	if escaped {
+		if e.Path, ok = unescapeChecksumPath(e.Path); !ok {
+			return nil, false
+		}
+	}
+	return e, true
+}
+
+// checksumTag returns the tag of the algorithm in the ChecksumTag format, compatible with the GNU coreutils
+func checksumTag(algorithm string) string {
+	switch {
+	case algorithm == BLAKE2b512Hash:
+		return "BLAKE2b"
+	case strings.HasPrefix(algorithm, "blake2"):
+		return "BLAKE2" + algorithm[len("blake2"):]
+	}
+	return strings.ToUpper(algorithm)
+}
+
+func escapeChecksumPath(path string) (string, bool) {
+	if !strings.ContainsAny(path, "\\\n\r") {
+		return path, false
+	}
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`).Replace(path), true
+}
+
+func unescapeChecksumPath(path string) (string, bool) {
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '\\' {
+			sb.WriteByte(path[i])
+			continue
+		}
+		i++
+		if i >= len(path) {
+			return "", false
+		}
+		switch path[i] {
+		case '\\':
+			sb.WriteByte('\\')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+func isHexString(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,238 @@
+package hashutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteChecksums(t *testing.T) {
+	root := newTestManifestDir(t)
+	path := filepath.Join(root, "a.txt")
+	slashPath := filepath.ToSlash(path)
+	testCases := []struct {
+		name      string
+		algorithm string
+		format    ChecksumFormat
+		expect    string
+	}{
+		{"md5 text", MD5Hash, ChecksumText, "0e181d273c0a4593230ce8319eaae9b7  " + slashPath + "\n"},
+		{"sha256 binary", SHA256Hash, ChecksumBinary, "f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c *" + slashPath + "\n"},
+		{"sha256 tag", SHA256Hash, ChecksumTag, "SHA256 (" + slashPath + ") = f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := NewHash(tc.algorithm)
+			if err != nil {
+				t.Errorf("NewHash error => %v", err)
+				return
+			}
+			var buf bytes.Buffer
+			if err = WriteChecksums(h, &buf, tc.format, path); err != nil {
+				t.Errorf("test WriteChecksums error => %v", err)
+				return
+			}
+			if buf.String() != tc.expect {
+				t.Errorf("test WriteChecksums error, expect to get %q, but actual get %q", tc.expect, buf.String())
+			}
+		})
+	}
+}
+
+func TestWriteChecksums_WithEncoding(t *testing.T) {
+	root := newTestManifestDir(t)
+	path := filepath.Join(root, "a.txt")
+	h, err := NewHash(SHA256Hash, WithEncoding(EncodingBase64))
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	var buf bytes.Buffer
+	if err = WriteChecksums(h, &buf, ChecksumText, path); err != nil {
+		t.Errorf("test WriteChecksums error => %v", err)
+		return
+	}
+	// the checksum file always uses the hex encoding
+	expect := "f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c  " + filepath.ToSlash(path) + "\n"
+	if buf.String() != expect {
+		t.Errorf("test WriteChecksums error, expect to get %q, but actual get %q", expect, buf.String())
+	}
+}
+
+func TestWriteChecksums_ReturnError(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteChecksums(testHash, &buf, ChecksumText, notExistFilePath); !os.IsNotExist(err) {
+		t.Errorf("test WriteChecksums error, expect to get error %v, but actual get %v", os.ErrNotExist, err)
+	}
+}
+
+func TestChecksumEntry_String(t *testing.T) {
+	testCases := []struct {
+		name   string
+		entry  ChecksumEntry
+		expect string
+	}{
+		{"text", ChecksumEntry{Format: ChecksumText, Path: "a b.txt", Hash: "00ff"}, "00ff  a b.txt"},
+		{"binary", ChecksumEntry{Format: ChecksumBinary, Path: "a.txt", Hash: "00ff"}, "00ff *a.txt"},
+		{"tag", ChecksumEntry{Format: ChecksumTag, Algorithm: SHA512Hash, Path: "a.txt", Hash: "00ff"}, "SHA512 (a.txt) = 00ff"},
+		{"blake2b-512 tag", ChecksumEntry{Format: ChecksumTag, Algorithm: BLAKE2b512Hash, Path: "a.txt", Hash: "00ff"}, "BLAKE2b (a.txt) = 00ff"},
+		{"blake2b-256 tag", ChecksumEntry{Format: ChecksumTag, Algorithm: BLAKE2b256Hash, Path: "a.txt", Hash: "00ff"}, "BLAKE2b-256 (a.txt) = 00ff"},
+		{"escaped path", ChecksumEntry{Format: ChecksumText, Path: "a\\b\nc.txt", Hash: "00ff"}, `\00ff  a\\b\nc.txt`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.entry.String()
+			if actual != tc.expect {
+				t.Errorf("test ChecksumEntry String error, expect to get %q, but actual get %q", tc.expect, actual)
+				return
+			}
+			entries, err := ParseChecksums(strings.NewReader(actual))
+			if err != nil {
+				t.Errorf("test ParseChecksums error => %v", err)
+				return
+			}
+			if len(entries) != 1 || *entries[0] != tc.entry {
+				t.Errorf("test ParseChecksums error, expect to get %+v, but actual get %+v", tc.entry, entries)
+			}
+		})
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	content := "0e181d273c0a4593230ce8319eaae9b7  a.txt\r\n" +
+		"\n" +
+		"0e181d273c0a4593230ce8319eaae9b7 *b.txt\n" +
+		"SHA256 (c (1).txt) = f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c\n" +
+		"0e181d273c0a4593230ce8319eaae9b7   d.txt\n"
+	expect := []ChecksumEntry{
+		{Format: ChecksumText, Path: "a.txt", Hash: "0e181d273c0a4593230ce8319eaae9b7"},
+		{Format: ChecksumBinary, Path: "b.txt", Hash: "0e181d273c0a4593230ce8319eaae9b7"},
+		{Format: ChecksumTag, Algorithm: SHA256Hash, Path: "c (1).txt", Hash: "f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c"},
+		{Format: ChecksumText, Path: " d.txt", Hash: "0e181d273c0a4593230ce8319eaae9b7"},
+	}
+	entries, err := ParseChecksums(strings.NewReader(content))
+	if err != nil {
+		t.Errorf("test ParseChecksums error => %v", err)
+		return
+	}
+	if len(entries) != len(expect) {
+		t.Errorf("test ParseChecksums error, expect to get %d entries, but actual get %d", len(expect), len(entries))
+		return
+	}
+	for i, e := range entries {
+		if *e != expect[i] {
+			t.Errorf("test ParseChecksums error, expect to get %+v, but actual get %+v", expect[i], *e)
+		}
+	}
+}
+
+func TestParseChecksums_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{"no path", "0e181d273c0a4593230ce8319eaae9b7"},
+		{"one space", "0e181d273c0a4593230ce8319eaae9b7 a.txt"},
+		{"invalid hash", "xyz  a.txt"},
+		{"invalid tag hash", "MD5 (a.txt) = xyz"},
+		{"invalid escape", `\0e181d273c0a4593230ce8319eaae9b7  a\t.txt`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseChecksums(strings.NewReader(tc.content))
+			if err == nil {
+				t.Errorf("test ParseChecksums error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	root := newTestManifestDir(t)
+	content := "0e181d273c0a4593230ce8319eaae9b7  a.txt\n" +
+		"SHA256 (a.txt) = F7392C4F40EB32D21E6DC087A00049E914F6CE69B76271F46F2B91C53F35166C\n" +
+		"0e181d273c0a4593230ce8319eaae9b7 *b.go\n" +
+		"SHA256 (sub/c.txt) = e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n" +
+		"0e181d273c0a4593230ce8319eaae9b7  not_exist.txt\n" +
+		"NOTEXIST (a.txt) = 00ff\n"
+	report, err := VerifyChecksums(testHash, strings.NewReader(content), root)
+	if err != nil {
+		t.Errorf("test VerifyChecksums error => %v", err)
+		return
+	}
+	expect := []struct {
+		path      string
+		algorithm string
+		status    ChecksumStatus
+	}{
+		{"a.txt", MD5Hash, ChecksumOK},
+		{"a.txt", SHA256Hash, ChecksumOK},
+		{"b.go", MD5Hash, ChecksumFailed},
+		{"sub/c.txt", SHA256Hash, ChecksumOK},
+		{"not_exist.txt", MD5Hash, ChecksumMissing},
+		{"a.txt", "notexist", ChecksumFailed},
+	}
+	if len(report.Results) != len(expect) {
+		t.Errorf("test VerifyChecksums error, expect to get %d results, but actual get %d", len(expect), len(report.Results))
+		return
+	}
+	for i, r := range report.Results {
+		if r.Path != expect[i].path || r.Algorithm != expect[i].algorithm || r.Status != expect[i].status {
+			t.Errorf("test VerifyChecksums error, expect to get %+v, but actual get %+v", expect[i], *r)
+		}
+	}
+	if err = report.Results[len(report.Results)-1].Err; err == nil {
+		t.Errorf("test VerifyChecksums error, expect to get an error with the unsupported algorithm but get nil")
+	}
+	if report.Passed != 3 || report.Failed != 2 || report.Missing != 1 || report.OK() {
+		t.Errorf("test VerifyChecksums error, get unexpected summary passed=%d failed=%d missing=%d", report.Passed, report.Failed, report.Missing)
+	}
+}
+
+func TestVerifyChecksums_WriteAndVerify(t *testing.T) {
+	root := newTestManifestDir(t)
+	var buf bytes.Buffer
+	paths := []string{filepath.Join(root, "a.txt"), filepath.Join(root, "b.go"), filepath.Join(root, "sub", "c.txt")}
+	if err := WriteChecksums(testHash, &buf, ChecksumTag, paths...); err != nil {
+		t.Errorf("test WriteChecksums error => %v", err)
+		return
+	}
+	report, err := VerifyChecksums(testHash, &buf, "")
+	if err != nil {
+		t.Errorf("test VerifyChecksums error => %v", err)
+		return
+	}
+	if !report.OK() || report.Passed != len(paths) {
+		t.Errorf("test VerifyChecksums error, expect all the files are passed, but actual get passed=%d failed=%d missing=%d", report.Passed, report.Failed, report.Missing)
+	}
+}
+
+func TestVerifyChecksums_ReturnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	testCases := []struct {
+		name    string
+		ctx     context.Context
+		content string
+		expect  error
+	}{
+		{"improperly formatted", context.Background(), "hello", nil},
+		{"context canceled", ctx, "00ff  " + testFilePath, context.Canceled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := VerifyChecksumsContext(tc.ctx, testHash, strings.NewReader(tc.content), "")
+			if err == nil || (tc.expect != nil && !errors.Is(err, tc.expect)) {
+				t.Errorf("test VerifyChecksums error, expect to get an error %v but actual get %v", tc.expect, err)
+			}
+		})
+	}
+}
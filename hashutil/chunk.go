@@ -0,0 +1,162 @@
+package hashutil
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// ChunkHash the hash info of a file chunk
+type ChunkHash struct {
+	// Offset the start position of the chunk in the file
+	Offset int64 `json:"offset"`
+	// Length the length of the chunk
+	Length int64 `json:"length"`
+	// Hash the chunk hash value
+	Hash string `json:"hash"`
+}
+
+// NewChunkHash returns an instance of ChunkHash
+func NewChunkHash(offset int64, length int64, hash string) *ChunkHash {
+	return &ChunkHash{
+		Offset: offset,
+		Length: length,
+		Hash:   hash,
+	}
+}
+
+// ChunkHashes the ordered list of *ChunkHash
+type ChunkHashes []*ChunkHash
+
+// ChunksHashFromFileName calculate the hash value of every chunk of the file independently by the specified count of
+// worker goroutines with ReadAt, and returns the ordered list of the chunk hash values.
+// If the workers is less than or equal to zero, use the number of logical CPUs instead
+func ChunksHashFromFileName(h Hash, path string, chunkSize int64, workers int) (chunks ChunkHashes, err error) {
+	return ChunksHashFromFileNameContext(context.Background(), h, path, chunkSize, workers)
+}
+
+// ChunksHashFromFileNameContext is like ChunksHashFromFileName but returns the ctx.Err() when the ctx is done
+func ChunksHashFromFileNameContext(ctx context.Context, h Hash, path string, chunkSize int64, workers int) (chunks ChunkHashes, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, err
+	}
+	f, err := dh.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r, workers := fileReaderAt(f, workers)
+	return dh.chunksHashFromReaderAt(ctx, r, stat.Size(), chunkSize, workers)
+}
+
+// ChunksHashFromReaderAt calculate the hash value of every chunk of the reader independently by the specified count of
+// worker goroutines, the size is the count of bytes to read from the reader
+func ChunksHashFromReaderAt(h Hash, r io.ReaderAt, size int64, chunkSize int64, workers int) (chunks ChunkHashes, err error) {
+	return ChunksHashFromReaderAtContext(context.Background(), h, r, size, chunkSize, workers)
+}
+
+// ChunksHashFromReaderAtContext is like ChunksHashFromReaderAt but returns the ctx.Err() when the ctx is done
+func ChunksHashFromReaderAtContext(ctx context.Context, h Hash, r io.ReaderAt, size int64, chunkSize int64, workers int) (chunks ChunkHashes, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, err
+	}
+	return dh.chunksHashFromReaderAt(ctx, r, size, chunkSize, workers)
+}
+
+func (dh *defaultHash) chunksHashFromReaderAt(ctx context.Context, r io.ReaderAt, size int64, chunkSize int64, workers int) (chunks ChunkHashes, err error) {
+	if err = dh.checkTextEncoding(); err != nil {
+		return nil, err
+	}
+	sums, err := dh.hashChunks(ctx, r, size, chunkSize, workers, nil)
+	if err != nil {
+		return nil, err
+	}
+	chunks = make(ChunkHashes, len(sums))
+	for i, sum := range sums {
+		offset := int64(i) * chunkSize
+		chunks[i] = NewChunkHash(offset, min(chunkSize, size-offset), dh.encode(sum))
+	}
+	return chunks, nil
+}
+
+// hashChunks calculate the hash sum of every chunk in parallel, the prefix is written to the hasher before the chunk data
+func (dh *defaultHash) hashChunks(ctx context.Context, r io.ReaderAt, size int64, chunkSize int64, workers int, prefix []byte) (sums [][]byte, err error) {
+	if r == nil {
+		return nil, errNilFile
+	}
+	if chunkSize <= 0 {
+		return nil, errChunkSizeInvalid
+	}
+	if size < 0 {
+		return nil, errSizeInvalid
+	}
+	count := int((size + chunkSize - 1) / chunkSize)
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, count)
+	sums = make([][]byte, count)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var readErr error
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := dh.new()
+			chunk := make([]byte, chunkSize)
+			for index := range indexes {
+				offset := int64(index) * chunkSize
+				data := chunk[:min(chunkSize, size-offset)]
+				n, err := r.ReadAt(data, offset)
+				if err == io.EOF && n == len(data) {
+					err = nil
+				} else if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				if err != nil {
+					errOnce.Do(func() {
+						readErr = err
+					})
+					cancel()
+					continue
+				}
+				h.Reset()
+				h.Write(prefix)
+				h.Write(data)
+				sums[index] = h.Sum(nil)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
@@ -0,0 +1,130 @@
+package hashutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestChunksHashFromFileName(t *testing.T) {
+	stat, err := os.Stat(testFilePath)
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+	fileSize := stat.Size()
+
+	testCases := []struct {
+		name      string
+		chunkSize int64
+		workers   int
+	}{
+		{"default workers", 100, 0},
+		{"single worker", 100, 1},
+		{"multiple workers", 33, 4},
+		{"more workers than chunks", fileSize, 8},
+		{"chunk larger than file", fileSize * 2, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks, err := ChunksHashFromFileName(testHash, testFilePath, tc.chunkSize, tc.workers)
+			if err != nil {
+				t.Errorf("test ChunksHashFromFileName error => %v", err)
+				return
+			}
+			expectCount := int((fileSize + tc.chunkSize - 1) / tc.chunkSize)
+			if len(chunks) != expectCount {
+				t.Errorf("test ChunksHashFromFileName error, expect to get %d chunks, but actual get %d", expectCount, len(chunks))
+				return
+			}
+			var offset int64
+			for _, chunk := range chunks {
+				if chunk.Offset != offset {
+					t.Errorf("test ChunksHashFromFileName error, expect the chunk offset %d, but actual get %d", offset, chunk.Offset)
+					return
+				}
+				expect, err := testHash.HashFromFileChunk(testFilePath, chunk.Offset, tc.chunkSize)
+				if err != nil {
+					t.Errorf("test HashFromFileChunk error => %v", err)
+					return
+				}
+				if chunk.Hash != expect {
+					t.Errorf("test ChunksHashFromFileName error, expect: %s, but actual: %s", expect, chunk.Hash)
+				}
+				offset += chunk.Length
+			}
+			if offset != fileSize {
+				t.Errorf("test ChunksHashFromFileName error, expect the total length %d, but actual get %d", fileSize, offset)
+			}
+		})
+	}
+}
+
+func TestChunksHashFromReaderAt_EmptyReader(t *testing.T) {
+	chunks, err := ChunksHashFromReaderAt(testHash, bytes.NewReader(nil), 0, 100, 2)
+	if err != nil {
+		t.Errorf("test ChunksHashFromReaderAt error => %v", err)
+		return
+	}
+	if len(chunks) != 0 {
+		t.Errorf("test ChunksHashFromReaderAt error, expect to get no chunk, but actual get %d", len(chunks))
+	}
+}
+
+func TestChunksHashFromReaderAt_ReturnError(t *testing.T) {
+	data := []byte("hello gopher")
+	testCases := []struct {
+		name      string
+		r         *bytes.Reader
+		size      int64
+		chunkSize int64
+	}{
+		{"nil reader", nil, 10, 100},
+		{"zero chunk size", bytes.NewReader(data), int64(len(data)), 0},
+		{"negative size", bytes.NewReader(data), -1, 100},
+		{"size larger than data", bytes.NewReader(data), int64(len(data)) + 10, 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			if tc.r == nil {
+				_, err = ChunksHashFromReaderAt(testHash, nil, tc.size, tc.chunkSize, 2)
+			} else {
+				_, err = ChunksHashFromReaderAt(testHash, tc.r, tc.size, tc.chunkSize, 2)
+			}
+			if err == nil {
+				t.Errorf("test ChunksHashFromReaderAt error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestChunksHashFromFileName_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{"with empty path", ""},
+		{"with not exist file path", notExistFilePath},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ChunksHashFromFileName(testHash, tc.path, 100, 2); err == nil {
+				t.Errorf("test ChunksHashFromFileName error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestChunksHashFromFileNameContext_ReturnCanceledError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ChunksHashFromFileNameContext(ctx, testHash, testFilePath, 10, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("test ChunksHashFromFileNameContext error, expect to get error %v, but actual get %v", context.Canceled, err)
+	}
+}
@@ -1,29 +1,103 @@
 package hashutil
 
 import (
-	"encoding/hex"
+	"context"
+	"hash"
 	"io"
+	"io/fs"
 	"os"
 	"time"
 )
 
+const (
+	// CompareReasonSize the sizes of the files are different
+	CompareReasonSize CompareReason = "size"
+	// CompareReasonHash the hash values of the files are different
+	CompareReasonHash CompareReason = "hash"
+	// CompareReasonData the data of the streams are different, only for comparing two streams without hashing
+	CompareReasonData CompareReason = "data"
+	// CompareReasonError failed to compare the files because of the error
+	CompareReasonError CompareReason = "error"
+)
+
+// CompareReason the reason why the files are not equal
+type CompareReason string
+
+// CompareResult the result of comparing the files
+type CompareResult struct {
+	// Equal whether the files are equal
+	Equal bool
+	// Reason the reason why the files are not equal, empty if they are equal
+	Reason CompareReason
+	// LastMatch the last continuous matching checkpoint, nil if no checkpoint is matched
+	// or comparing two streams without hashing
+	LastMatch *HashValue
+	// Mismatch the first mismatching checkpoint of the source, nil if no checkpoint is mismatched
+	// or comparing two streams without hashing
+	Mismatch *HashValue
+	// Offset the offset to resume the transfer from, it is the offset of the LastMatch or zero,
+	// or the DiffOffset when comparing two streams without hashing
+	Offset int64
+	// DiffOffset the offset of the first differing byte if they are not equal. It is exact when comparing two streams,
+	// otherwise the data of the source is unavailable, and it is the offset of the LastMatch that the first differing
+	// byte is after
+	DiffOffset int64
+	// Err the error of reading the files, only for the CompareReasonError
+	Err error
+}
+
+func newCompareErrorResult(err error) *CompareResult {
+	return &CompareResult{
+		Reason: CompareReasonError,
+		Err:    err,
+	}
+}
+
 func (dh *defaultHash) Compare(chunkSize int64, checkpointCount int, sourceFile *os.File, sourceSize int64, dest string, destSize int64, offset *int64) (equal bool) {
+	return dh.CompareContext(context.Background(), chunkSize, checkpointCount, sourceFile, sourceSize, dest, destSize, offset)
+}
+
+func (dh *defaultHash) CompareContext(ctx context.Context, chunkSize int64, checkpointCount int, sourceFile *os.File, sourceSize int64, dest string, destSize int64, offset *int64) (equal bool) {
 	if destSize <= 0 {
 		return false
 	}
-	hvs, _ := dh.CheckpointsHashFromFile(sourceFile, chunkSize, checkpointCount)
-	if len(hvs) > 0 && hvs.Last().Offset == sourceSize {
-		// if source and dest is the same file, ignore the following steps and return directly
-		equal, hv := dh.CompareHashValues(dest, sourceSize, hvs.Last().Hash, chunkSize, hvs)
-		if equal {
-			return equal
-		}
+	result := dh.compareFile(ctx, chunkSize, checkpointCount, sourceFile, sourceSize, dest)
+	if !result.Equal && result.LastMatch != nil {
+		*offset = result.Offset
+	}
+	return result.Equal
+}
 
-		if hv != nil {
-			*offset = hv.Offset
-		}
+// CompareFile compare the source file with the destination file like Hash.Compare, but returns the CompareResult that
+// contains the reason, the last matching checkpoint, the resumable offset and the error instead.
+// The source file can be an *os.File or a file opened from any fs.FS, and the size of the destination file is read
+// from the file itself
+func CompareFile(h Hash, chunkSize int64, checkpointCount int, sourceFile fs.File, sourceSize int64, dest string) *CompareResult {
+	return CompareFileContext(context.Background(), h, chunkSize, checkpointCount, sourceFile, sourceSize, dest)
+}
+
+// CompareFileContext is like CompareFile but stops comparing and returns the ctx.Err() in the CompareResult when the ctx is done
+func CompareFileContext(ctx context.Context, h Hash, chunkSize int64, checkpointCount int, sourceFile fs.File, sourceSize int64, dest string) *CompareResult {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return newCompareErrorResult(err)
 	}
-	return false
+	return dh.compareFile(ctx, chunkSize, checkpointCount, sourceFile, sourceSize, dest)
+}
+
+func (dh *defaultHash) compareFile(ctx context.Context, chunkSize int64, checkpointCount int, sourceFile fs.File, sourceSize int64, dest string) *CompareResult {
+	if isNilFile(sourceFile) {
+		return newCompareErrorResult(errNilFile)
+	}
+	hvs, err := dh.checkpointsHashFromFile(ctx, sourceFile, chunkSize, checkpointCount)
+	if err != nil {
+		return newCompareErrorResult(err)
+	}
+	if hvs.Last().Offset != sourceSize {
+		// the source file is changed
+		return &CompareResult{Reason: CompareReasonSize}
+	}
+	return dh.compareFileHashValues(ctx, dest, sourceSize, hvs.Last().Hash, chunkSize, hvs)
 }
 
 func (dh *defaultHash) QuickCompare(forceChecksum bool, sourceSize, destSize int64, sourceModTime, destModTime time.Time) (equal bool) {
@@ -34,56 +108,299 @@ func (dh *defaultHash) QuickCompare(forceChecksum bool, sourceSize, destSize int
 }
 
 func (dh *defaultHash) CompareHashValues(dstPath string, sourceSize int64, sourceHash string, chunkSize int64, hvs HashValues) (equal bool, hv *HashValue) {
-	if sourceSize > 0 {
-		// calculate the entire file hash value
-		if len(hvs) == 0 || hvs.Last().Offset < sourceSize {
-			hvs = append(hvs, NewHashValue(sourceSize, sourceHash))
+	return dh.CompareHashValuesContext(context.Background(), dstPath, sourceSize, sourceHash, chunkSize, hvs)
+}
+
+func (dh *defaultHash) CompareHashValuesContext(ctx context.Context, dstPath string, sourceSize int64, sourceHash string, chunkSize int64, hvs HashValues) (equal bool, hv *HashValue) {
+	result := dh.compareFileHashValues(ctx, dstPath, sourceSize, sourceHash, chunkSize, hvs)
+	return result.Equal, result.LastMatch
+}
+
+// CompareFileHashValues compare the HashValues from source file with the destination file like Hash.CompareHashValues,
+// but returns the CompareResult instead
+func CompareFileHashValues(h Hash, dstPath string, sourceSize int64, sourceHash string, chunkSize int64, hvs HashValues) *CompareResult {
+	return CompareFileHashValuesContext(context.Background(), h, dstPath, sourceSize, sourceHash, chunkSize, hvs)
+}
+
+// CompareFileHashValuesContext is like CompareFileHashValues but stops comparing and returns the ctx.Err()
+// in the CompareResult when the ctx is done
+func CompareFileHashValuesContext(ctx context.Context, h Hash, dstPath string, sourceSize int64, sourceHash string, chunkSize int64, hvs HashValues) *CompareResult {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return newCompareErrorResult(err)
+	}
+	return dh.compareFileHashValues(ctx, dstPath, sourceSize, sourceHash, chunkSize, hvs)
+}
+
+func (dh *defaultHash) compareFileHashValues(ctx context.Context, dstPath string, sourceSize int64, sourceHash string, chunkSize int64, hvs HashValues) *CompareResult {
+	if sourceSize <= 0 {
+		return &CompareResult{Reason: CompareReasonSize}
+	}
+	hvs = appendEntireHashValue(hvs, sourceSize, sourceHash)
+	eq, mismatch, destSize, err := dh.compareHashValuesWithFileName(ctx, dstPath, chunkSize, hvs)
+	if err != nil {
+		return newCompareErrorResult(err)
+	}
+	return dh.newCompareResult(eq, mismatch, sourceSize, sourceHash, destSize)
+}
+
+// CompareReaderHashValues compare the HashValues from the source with the data read from the r like
+// CompareFileHashValues, the r is read in lockstep with the HashValues and stops at the first mismatching checkpoint,
+// so the data of the source is unavailable and the DiffOffset is the offset of the LastMatch
+func CompareReaderHashValues(h Hash, r io.Reader, sourceSize int64, sourceHash string, chunkSize int64, hvs HashValues) *CompareResult {
+	return CompareReaderHashValuesContext(context.Background(), h, r, sourceSize, sourceHash, chunkSize, hvs)
+}
+
+// CompareReaderHashValuesContext is like CompareReaderHashValues but stops comparing and returns the ctx.Err()
+// in the CompareResult when the ctx is done
+func CompareReaderHashValuesContext(ctx context.Context, h Hash, r io.Reader, sourceSize int64, sourceHash string, chunkSize int64, hvs HashValues) *CompareResult {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return newCompareErrorResult(err)
+	}
+	if r == nil {
+		return newCompareErrorResult(errNilFile)
+	}
+	if sourceSize <= 0 {
+		return &CompareResult{Reason: CompareReasonSize}
+	}
+	if chunkSize <= 0 {
+		return newCompareErrorResult(errChunkSizeInvalid)
+	}
+	hvs = appendEntireHashValue(hvs, sourceSize, sourceHash)
+	eq, mismatch, readLen, err := dh.compareHashValues(ctx, r, sourceSize, chunkSize, hvs)
+	if err != nil {
+		return newCompareErrorResult(err)
+	}
+	// the size of the stream is unknown if a checkpoint is mismatched before reading to the end
+	destSize := int64(-1)
+	if mismatch == nil {
+		destSize = readLen
+	}
+	if mismatch == nil && readLen == sourceSize {
+		// check whether the stream contains more data than the source
+		n, err := io.ReadFull(r, make([]byte, 1))
+		if err != nil && err != io.EOF {
+			return newCompareErrorResult(err)
 		}
-		hv, err := dh.CompareHashValuesWithFileName(dstPath, chunkSize, hvs)
-		if err == nil && hv != nil {
-			return hv.Offset == sourceSize && hv.Hash == sourceHash && len(sourceHash) > 0, hv
+		destSize += int64(n)
+	}
+	return dh.newCompareResult(eq, mismatch, sourceSize, sourceHash, destSize)
+}
+
+// CompareReaders compare the data of two streams chunk by chunk in lockstep without the temporary files,
+// the data is always compared byte by byte, and the DiffOffset is the exact offset of the first differing byte.
+// If the withHash is true, the src is hashed too, every chunk of the src is a checkpoint, the LastMatch and Mismatch
+// are the checkpoints around the first differing byte, the Offset is the offset of the LastMatch and the Reason of
+// the different data is the CompareReasonHash. Otherwise, the LastMatch and Mismatch are nil, the Offset is the
+// DiffOffset and the Reason of the different data is the CompareReasonData
+func CompareReaders(h Hash, src io.Reader, dst io.Reader, chunkSize int64, withHash bool) *CompareResult {
+	return CompareReadersContext(context.Background(), h, src, dst, chunkSize, withHash)
+}
+
+// CompareReadersContext is like CompareReaders but stops comparing and returns the ctx.Err()
+// in the CompareResult when the ctx is done
+func CompareReadersContext(ctx context.Context, h Hash, src io.Reader, dst io.Reader, chunkSize int64, withHash bool) *CompareResult {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return newCompareErrorResult(err)
+	}
+	if src == nil || dst == nil {
+		return newCompareErrorResult(errNilFile)
+	}
+	if chunkSize <= 0 {
+		return newCompareErrorResult(errChunkSizeInvalid)
+	}
+	// the chunks of the streams are compared byte by byte, so only the source is hashed to build the checkpoints
+	var hasher hash.Hash
+	if withHash {
+		hasher = dh.new()
+	}
+	srcChunk := make([]byte, chunkSize)
+	dstChunk := make([]byte, chunkSize)
+	result := &CompareResult{}
+	var offset int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return newCompareErrorResult(err)
+		}
+		sn, err := readChunk(src, srcChunk)
+		if err != nil {
+			return newCompareErrorResult(err)
+		}
+		dn, err := readChunk(dst, dstChunk)
+		if err != nil {
+			return newCompareErrorResult(err)
+		}
+		n := min(sn, dn)
+		i := 0
+		for i < n && srcChunk[i] == dstChunk[i] {
+			i++
+		}
+		if hasher != nil {
+			hasher.Write(srcChunk[:sn])
+		}
+		if i < n || sn != dn {
+			result.DiffOffset = offset + int64(i)
+			switch {
+			case i == n:
+				result.Reason = CompareReasonSize
+			case withHash:
+				result.Reason = CompareReasonHash
+			default:
+				result.Reason = CompareReasonData
+			}
+			if hasher != nil && sn > 0 {
+				result.Mismatch = NewHashValue(offset+int64(sn), dh.encode(hasher.Sum(nil)))
+			}
+			if !withHash {
+				result.Offset = result.DiffOffset
+			}
+			return result
+		}
+		if sn == 0 {
+			result.Equal = true
+			return result
+		}
+		offset += int64(sn)
+		result.Offset = offset
+		dh.progress.OnProgress(offset, -1)
+		if hasher != nil {
+			result.LastMatch = NewHashValue(offset, dh.encode(hasher.Sum(nil)))
+			dh.progress.OnCheckpoint(result.LastMatch)
 		}
 	}
-	return false, nil
+}
+
+// readChunk read the data to fill the chunk, returns the count of bytes read and nil error if reach the end of the r
+func readChunk(r io.Reader, chunk []byte) (int, error) {
+	n, err := io.ReadFull(r, chunk)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return n, err
+}
+
+// appendEntireHashValue append the hash value of the entire source to the HashValues if it is missing,
+// avoid modifying the backing array of the caller
+func appendEntireHashValue(hvs HashValues, sourceSize int64, sourceHash string) HashValues {
+	if len(hvs) == 0 || hvs.Last().Offset < sourceSize {
+		hvs = append(hvs[:len(hvs):len(hvs)], NewHashValue(sourceSize, sourceHash))
+	}
+	return hvs
+}
+
+// newCompareResult build the CompareResult with the last matching and the first mismatching checkpoints of the source,
+// the destSize is negative if the size of the destination is unknown
+func (dh *defaultHash) newCompareResult(eq *HashValue, mismatch *HashValue, sourceSize int64, sourceHash string, destSize int64) *CompareResult {
+	result := &CompareResult{
+		LastMatch: eq,
+		Mismatch:  mismatch,
+	}
+	if eq != nil {
+		result.Offset = eq.Offset
+	}
+	switch {
+	case destSize >= 0 && destSize != sourceSize:
+		result.Reason = CompareReasonSize
+	case eq != nil && eq.Offset == sourceSize && len(sourceHash) > 0 && dh.equalHash(eq.Hash, sourceHash):
+		result.Equal = true
+		return result
+	default:
+		result.Reason = CompareReasonHash
+	}
+	result.DiffOffset = result.Offset
+	return result
 }
 
 func (dh *defaultHash) CompareHashValuesWithFileName(path string, chunkSize int64, hvs HashValues) (eq *HashValue, err error) {
-	f, err := dh.open(path)
+	return dh.CompareHashValuesWithFileNameContext(context.Background(), path, chunkSize, hvs)
+}
+
+func (dh *defaultHash) CompareHashValuesWithFileNameContext(ctx context.Context, path string, chunkSize int64, hvs HashValues) (eq *HashValue, err error) {
+	eq, _, _, err = dh.compareHashValuesWithFileName(ctx, path, chunkSize, hvs)
+	return eq, err
+}
+
+// CompareHashValuesWithReaderAt is like Hash.CompareHashValuesWithFileName but reads the data from the reader,
+// the size is the count of bytes to read from the reader
+func CompareHashValuesWithReaderAt(h Hash, r io.ReaderAt, size int64, chunkSize int64, hvs HashValues) (eq *HashValue, err error) {
+	return CompareHashValuesWithReaderAtContext(context.Background(), h, r, size, chunkSize, hvs)
+}
+
+// CompareHashValuesWithReaderAtContext is like CompareHashValuesWithReaderAt but checks the ctx between chunks
+// and returns the ctx.Err() when the ctx is done
+func CompareHashValuesWithReaderAtContext(ctx context.Context, h Hash, r io.ReaderAt, size int64, chunkSize int64, hvs HashValues) (eq *HashValue, err error) {
+	dh, err := toDefaultHash(h)
 	if err != nil {
 		return nil, err
 	}
+	if r == nil {
+		return nil, errNilFile
+	}
+	if chunkSize <= 0 {
+		return nil, errChunkSizeInvalid
+	}
+	if size < 0 {
+		return nil, errSizeInvalid
+	}
+	eq, _, _, err = dh.compareHashValues(ctx, io.NewSectionReader(r, 0, size), size, chunkSize, hvs)
+	return eq, err
+}
+
+// compareHashValuesWithFileName calculate the file hashes and return the last continuous hit HashValue,
+// the first mismatching HashValue and the file size
+func (dh *defaultHash) compareHashValuesWithFileName(ctx context.Context, path string, chunkSize int64, hvs HashValues) (eq *HashValue, mismatch *HashValue, fileSize int64, err error) {
+	f, err := dh.open(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
 	defer f.Close()
 
 	if chunkSize <= 0 {
-		return nil, errChunkSizeInvalid
+		return nil, nil, 0, errChunkSizeInvalid
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, 0, err
 	}
+	fileSize = stat.Size()
+	eq, mismatch, _, err = dh.compareHashValues(ctx, f, fileSize, chunkSize, hvs)
+	return eq, mismatch, fileSize, err
+}
+
+// compareHashValues read the data from the r and return the last continuous hit HashValue, the first mismatching
+// HashValue and the count of bytes read, the mismatch is nil if reach the end of the r before the next HashValue
+func (dh *defaultHash) compareHashValues(ctx context.Context, r io.Reader, fileSize int64, chunkSize int64, hvs HashValues) (eq *HashValue, mismatch *HashValue, readLen int64, err error) {
 	if len(hvs) == 0 {
-		return nil, nil
+		return nil, nil, 0, nil
 	}
 	h := dh.new()
 	var writeLen int64
 	hvi := 0
 	hv := hvs[0]
-	isEOF := false
 	chunk := make([]byte, chunkSize)
 	// calculate hash
 	for {
-		n, err := f.Read(chunk)
-		if err == io.EOF {
-			isEOF = true
-			err = nil
+		if err := ctx.Err(); err != nil {
+			return nil, nil, writeLen, err
 		}
+		// read the entire chunk even if the r returns less data, keep the offsets of the checkpoints
+		n, err := readChunk(r, chunk)
 		if err != nil {
-			return nil, err
+			return nil, nil, writeLen, err
 		}
+		isEOF := n == 0
 
 		writeLen += int64(n)
 		h.Write(chunk[:n])
+		dh.progress.OnProgress(writeLen, fileSize)
 		if writeLen >= hv.Offset {
-			if writeLen != hv.Offset || hv.Hash != hex.EncodeToString(h.Sum(nil)) {
-				return eq, nil
+			if writeLen != hv.Offset || !dh.matchHash(hv.Hash, h.Sum(nil)) {
+				return eq, hv, writeLen, nil
 			}
 			eq = hv
+			dh.progress.OnCheckpoint(hv)
 			hvi++
 			if hvi < len(hvs) {
 				hv = hvs[hvi]
@@ -94,5 +411,5 @@ func (dh *defaultHash) CompareHashValuesWithFileName(path string, chunkSize int6
 			break
 		}
 	}
-	return eq, nil
+	return eq, nil, writeLen, nil
 }
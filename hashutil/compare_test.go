@@ -1,9 +1,14 @@
 package hashutil
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
@@ -65,6 +70,41 @@ func TestCompare(t *testing.T) {
 	}
 }
 
+func TestCompare_LongerDest(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	dest := filepath.Join(dir, "dest")
+	if err := os.WriteFile(source, []byte("hello gopher"), 0600); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	if err := os.WriteFile(dest, []byte("hello gopher, hello x"), 0600); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	src, err := os.Open(source)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer src.Close()
+
+	// the destination file starts with the data of the source file, but it is longer, so they are not equal,
+	// and the offset to resume from is the end of the source file
+	var offset int64
+	if testHash.Compare(4, 2, src, 12, dest, 21, &offset) || offset != 12 {
+		t.Errorf("test Compare error, expect get false with offset 12 but actual get true or offset %d", offset)
+	}
+	hvs, err := testHash.CheckpointsHashFromFileName(source, 4, 2)
+	if err != nil {
+		t.Errorf("get file hash error => %v", err)
+		return
+	}
+	if equal, hv := testHash.CompareHashValues(dest, 12, hvs.Last().Hash, 4, hvs); equal || hv == nil || hv.Offset != 12 {
+		t.Errorf("test CompareHashValues error, expect get false with the last match at 12 but actual get %v %v", equal, hv)
+	}
+}
+
 func TestQuickCompare(t *testing.T) {
 	now := time.Now()
 	testCases := []struct {
@@ -137,3 +177,335 @@ func TestCompareHashValues(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareContext_WithCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	src, err := os.Open(compareTestFile)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer src.Close()
+	srcStat, err := src.Stat()
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+
+	var offset int64
+	if testHash.CompareContext(ctx, 100, 10, src, srcStat.Size(), compareTestFile, 1, &offset) {
+		t.Errorf("test CompareContext error, expect get false with the canceled context but actual get true")
+	}
+
+	srcHash, err := testHash.HashFromFileName(compareTestFile)
+	if err != nil {
+		t.Errorf("get file hash error => %v", err)
+		return
+	}
+	if equal, _ := testHash.CompareHashValuesContext(ctx, compareTestFile, srcStat.Size(), srcHash, 100, nil); equal {
+		t.Errorf("test CompareHashValuesContext error, expect get false with the canceled context but actual get true")
+	}
+}
+
+func TestCompareFile(t *testing.T) {
+	tempFile, err := os.CreateTemp("", filepath.Clean(compareTestFile))
+	if err != nil {
+		t.Errorf("create temp file error => %v", err)
+		return
+	}
+	defer tempFile.Close()
+
+	testFileData, err := os.ReadFile(compareTestFile)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	_, err = tempFile.Write(testFileData[:len(testFileData)/2])
+	if err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	if err = os.WriteFile(emptyFile, nil, 0600); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	testCases := []struct {
+		name         string
+		dest         string
+		equal        bool
+		reason       CompareReason
+		hasLastMatch bool
+	}{
+		{"empty dest file", emptyFile, false, CompareReasonSize, false},
+		{"the same files", compareTestFile, true, "", true},
+		{"the different files", compareGoFile, false, CompareReasonSize, false},
+		{"only part of it is the same", tempFile.Name(), false, CompareReasonSize, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src, err := os.Open(compareTestFile)
+			if err != nil {
+				t.Errorf("open file error => %v", err)
+				return
+			}
+			defer src.Close()
+			srcStat, err := src.Stat()
+			if err != nil {
+				t.Errorf("get file stat error => %v", err)
+				return
+			}
+			result := CompareFile(testHash, 100, 10, src, srcStat.Size(), tc.dest)
+			if result.Equal != tc.equal || result.Reason != tc.reason || result.Err != nil {
+				t.Errorf("expect get equal=%v reason=%q but actual get equal=%v reason=%q err=%v", tc.equal, tc.reason, result.Equal, result.Reason, result.Err)
+			}
+			if (result.LastMatch != nil) != tc.hasLastMatch {
+				t.Errorf("expect the last match exists is %v, but actual get %v", tc.hasLastMatch, result.LastMatch)
+				return
+			}
+			if result.LastMatch != nil && result.Offset != result.LastMatch.Offset {
+				t.Errorf("expect the offset is %d, but actual get %d", result.LastMatch.Offset, result.Offset)
+			}
+		})
+	}
+}
+
+func TestCompareFile_ReturnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	src, err := os.Open(compareTestFile)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer src.Close()
+	srcStat, err := src.Stat()
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name   string
+		ctx    context.Context
+		src    *os.File
+		dest   string
+		expect error
+	}{
+		{"nil source file", context.Background(), nil, compareTestFile, errNilFile},
+		{"not exist dest file", context.Background(), src, notExistFilePath, os.ErrNotExist},
+		{"canceled context", ctx, src, compareTestFile, context.Canceled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.src != nil {
+				tc.src.Seek(0, io.SeekStart)
+			}
+			result := CompareFileContext(tc.ctx, testHash, 100, 10, tc.src, srcStat.Size(), tc.dest)
+			if result.Equal || result.Reason != CompareReasonError || !errors.Is(result.Err, tc.expect) {
+				t.Errorf("expect get the error %v but actual get equal=%v reason=%q err=%v", tc.expect, result.Equal, result.Reason, result.Err)
+			}
+		})
+	}
+}
+
+func TestCompareFileHashValues(t *testing.T) {
+	srcStat, err := os.Stat(compareTestFile)
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+	hvs, err := testHash.CheckpointsHashFromFileName(compareTestFile, 100, 10)
+	if err != nil {
+		t.Errorf("get file hash error => %v", err)
+		return
+	}
+	srcHash := hvs.Last().Hash
+	testCases := []struct {
+		name       string
+		dstPath    string
+		sourceSize int64
+		sourceHash string
+		equal      bool
+		reason     CompareReason
+	}{
+		{"zero source size", compareTestFile, 0, srcHash, false, CompareReasonSize},
+		{"the same files", compareTestFile, srcStat.Size(), srcHash, true, ""},
+		{"the different hash", compareTestFile, srcStat.Size(), testHash.HashFromString("hello"), false, CompareReasonHash},
+		{"not exist dest file", notExistFilePath, srcStat.Size(), srcHash, false, CompareReasonError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := CompareFileHashValues(testHash, tc.dstPath, tc.sourceSize, tc.sourceHash, 100, hvs[:len(hvs)-1])
+			if result.Equal != tc.equal || result.Reason != tc.reason {
+				t.Errorf("expect get equal=%v reason=%q but actual get equal=%v reason=%q err=%v", tc.equal, tc.reason, result.Equal, result.Reason, result.Err)
+			}
+		})
+	}
+}
+
+func TestCompareReaders(t *testing.T) {
+	data := []byte("hello gopher, hello world, hello golang!")
+	modified := bytes.Clone(data)
+	modified[25] = '!'
+	testCases := []struct {
+		name       string
+		dst        []byte
+		withHash   bool
+		equal      bool
+		reason     CompareReason
+		diffOffset int64
+		offset     int64
+		mismatch   int64
+	}{
+		{"the same data", data, true, true, "", 0, 40, 0},
+		{"the same data without hash", data, false, true, "", 0, 40, 0},
+		{"the different data", modified, true, false, CompareReasonHash, 25, 20, 30},
+		{"the different data without hash", modified, false, false, CompareReasonData, 25, 25, 0},
+		{"the shorter dst", data[:23], true, false, CompareReasonSize, 23, 20, 30},
+		{"the shorter dst without hash", data[:23], false, false, CompareReasonSize, 23, 23, 0},
+		{"the longer dst", append(bytes.Clone(data), '!'), true, false, CompareReasonSize, 40, 40, 0},
+		{"the empty dst", nil, true, false, CompareReasonSize, 0, 0, 10},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// the short reads don't affect the checkpoints
+			src := iotest.OneByteReader(bytes.NewReader(data))
+			dst := iotest.HalfReader(bytes.NewReader(tc.dst))
+			result := CompareReaders(testHash, src, dst, 10, tc.withHash)
+			if result.Equal != tc.equal || result.Reason != tc.reason || result.Err != nil {
+				t.Errorf("expect get equal=%v reason=%q but actual get equal=%v reason=%q err=%v", tc.equal, tc.reason, result.Equal, result.Reason, result.Err)
+				return
+			}
+			if result.DiffOffset != tc.diffOffset || result.Offset != tc.offset {
+				t.Errorf("expect get diff offset=%d offset=%d but actual get diff offset=%d offset=%d", tc.diffOffset, tc.offset, result.DiffOffset, result.Offset)
+			}
+			if !tc.withHash {
+				if result.LastMatch != nil || result.Mismatch != nil {
+					t.Errorf("expect no checkpoint is reported without hash")
+				}
+				return
+			}
+			if result.LastMatch == nil && tc.offset > 0 {
+				t.Errorf("expect the last matching checkpoint is reported")
+			}
+			if result.LastMatch != nil && (result.LastMatch.Offset != tc.offset || result.LastMatch.Hash != testHash.Hash(data[:tc.offset])) {
+				t.Errorf("expect the last matching checkpoint is %d, but actual get %v", tc.offset, result.LastMatch)
+			}
+			if tc.mismatch == 0 && result.Mismatch != nil {
+				t.Errorf("expect no mismatching checkpoint, but actual get %v", result.Mismatch)
+			}
+			if tc.mismatch > 0 && (result.Mismatch == nil || result.Mismatch.Offset != tc.mismatch || result.Mismatch.Hash != testHash.Hash(data[:tc.mismatch])) {
+				t.Errorf("expect the mismatching checkpoint is %d, but actual get %v", tc.mismatch, result.Mismatch)
+			}
+		})
+	}
+}
+
+func TestCompareReaders_ReturnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	readErr := errors.New("read error")
+	testCases := []struct {
+		name      string
+		ctx       context.Context
+		src       io.Reader
+		dst       io.Reader
+		chunkSize int64
+		expect    error
+	}{
+		{"nil src", context.Background(), nil, bytes.NewReader(nil), 10, errNilFile},
+		{"nil dst", context.Background(), bytes.NewReader(nil), nil, 10, errNilFile},
+		{"zero chunk size", context.Background(), bytes.NewReader(nil), bytes.NewReader(nil), 0, errChunkSizeInvalid},
+		{"src read error", context.Background(), iotest.ErrReader(readErr), bytes.NewReader(nil), 10, readErr},
+		{"dst read error", context.Background(), bytes.NewReader(nil), iotest.ErrReader(readErr), 10, readErr},
+		{"canceled context", ctx, bytes.NewReader(nil), bytes.NewReader(nil), 10, context.Canceled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := CompareReadersContext(tc.ctx, testHash, tc.src, tc.dst, tc.chunkSize, true)
+			if result.Equal || result.Reason != CompareReasonError || !errors.Is(result.Err, tc.expect) {
+				t.Errorf("expect get the error %v but actual get equal=%v reason=%q err=%v", tc.expect, result.Equal, result.Reason, result.Err)
+			}
+		})
+	}
+}
+
+func TestCompareReaderHashValues(t *testing.T) {
+	data := []byte("hello gopher, hello world, hello golang!")
+	size := int64(len(data))
+	hvs, err := CheckpointsHashFromReaderAt(testHash, bytes.NewReader(data), size, 10, 4)
+	if err != nil {
+		t.Errorf("get hash values error => %v", err)
+		return
+	}
+	hash := hvs.Last().Hash
+	modified := bytes.Clone(data)
+	modified[25] = '!'
+	testCases := []struct {
+		name       string
+		dst        []byte
+		sourceSize int64
+		sourceHash string
+		equal      bool
+		reason     CompareReason
+		lastMatch  int64
+		mismatch   int64
+	}{
+		{"the same data", data, size, hash, true, "", 40, 0},
+		{"the different data", modified, size, hash, false, CompareReasonHash, 20, 30},
+		{"the different hash", data, size, testHash.HashFromString("hello"), false, CompareReasonHash, 40, 0},
+		{"the shorter dst", data[:23], size, hash, false, CompareReasonSize, 20, 0},
+		{"the longer dst", append(bytes.Clone(data), '!'), size, hash, false, CompareReasonSize, 40, 0},
+		{"zero source size", data, 0, hash, false, CompareReasonSize, 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := CompareReaderHashValues(testHash, iotest.HalfReader(bytes.NewReader(tc.dst)), tc.sourceSize, tc.sourceHash, 10, hvs[:len(hvs)-1])
+			if result.Equal != tc.equal || result.Reason != tc.reason || result.Err != nil {
+				t.Errorf("expect get equal=%v reason=%q but actual get equal=%v reason=%q err=%v", tc.equal, tc.reason, result.Equal, result.Reason, result.Err)
+				return
+			}
+			if result.Offset != tc.lastMatch || (!tc.equal && result.DiffOffset != tc.lastMatch) {
+				t.Errorf("expect get offset=%d diff offset=%d but actual get offset=%d diff offset=%d", tc.lastMatch, tc.lastMatch, result.Offset, result.DiffOffset)
+			}
+			if tc.mismatch == 0 && result.Mismatch != nil {
+				t.Errorf("expect no mismatching checkpoint, but actual get %v", result.Mismatch)
+			}
+			if tc.mismatch > 0 && (result.Mismatch == nil || result.Mismatch.Offset != tc.mismatch) {
+				t.Errorf("expect the mismatching checkpoint is %d, but actual get %v", tc.mismatch, result.Mismatch)
+			}
+		})
+	}
+}
+
+func TestCompareReaderHashValues_ReturnError(t *testing.T) {
+	readErr := errors.New("read error")
+	testCases := []struct {
+		name      string
+		r         io.Reader
+		chunkSize int64
+		expect    error
+	}{
+		{"nil reader", nil, 10, errNilFile},
+		{"zero chunk size", bytes.NewReader(nil), 0, errChunkSizeInvalid},
+		{"read error", iotest.ErrReader(readErr), 10, readErr},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := CompareReaderHashValues(testHash, tc.r, 10, testHash.HashFromString("hello"), tc.chunkSize, nil)
+			if result.Equal || result.Reason != CompareReasonError || !errors.Is(result.Err, tc.expect) {
+				t.Errorf("expect get the error %v but actual get equal=%v reason=%q err=%v", tc.expect, result.Equal, result.Reason, result.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package hashutil
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader an io.Reader that returns the ctx.Err() once the ctx is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// newContextReader returns an io.Reader that checks the ctx before every read,
+// the original reader is returned directly if the ctx can never be canceled
+func newContextReader(ctx context.Context, r io.Reader) io.Reader {
+	if ctx.Done() == nil {
+		return r
+	}
+	return &contextReader{ctx: ctx, r: r}
+}
+
+func (cr *contextReader) Read(p []byte) (n int, err error) {
+	if err = cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
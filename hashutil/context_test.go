@@ -0,0 +1,32 @@
+package hashutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewContextReader(t *testing.T) {
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name   string
+		ctx    context.Context
+		expect error
+	}{
+		{"background context", context.Background(), nil},
+		{"canceled context", canceledCtx, context.Canceled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newContextReader(tc.ctx, strings.NewReader("hello gopher"))
+			_, err := r.Read(make([]byte, 4))
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("test newContextReader error, expect to get error %v, but actual get %v", tc.expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package hashutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"io"
+)
+
+const (
+	// DeltaOpCopy the delta instruction to copy the data from the base
+	DeltaOpCopy DeltaOpType = "copy"
+	// DeltaOpLiteral the delta instruction to write the literal data
+	DeltaOpLiteral DeltaOpType = "literal"
+
+	// maxDeltaLiteralSize the max size of the data in a literal instruction
+	maxDeltaLiteralSize = 1024 * 1024
+)
+
+var (
+	errBlockSizeInvalid  = errors.New("block size must be greater than zero")
+	errNilSignature      = errors.New("signature is nil")
+	errNilDelta          = errors.New("delta is nil")
+	errDeltaSizeMismatch = errors.New("the size of the patched data does not match the delta")
+)
+
+// Signature the rsync-style signature of the base data
+type Signature struct {
+	// Algorithm the hash algorithm of the strong hash value
+	Algorithm string `json:"algorithm"`
+	// BlockSize the size of every block except the last one
+	BlockSize int `json:"block_size"`
+	// Size the total size of the base data
+	Size int64 `json:"size"`
+	// Blocks the checksums of all the blocks in order
+	Blocks []*BlockSignature `json:"blocks"`
+}
+
+// BlockSignature the checksums of a block of the base data
+type BlockSignature struct {
+	// Index the index of the block
+	Index int `json:"index"`
+	// Weak the rolling checksum of the block, equal to the Adler-32 checksum
+	Weak uint32 `json:"weak"`
+	// Strong the hash value of the block
+	Strong string `json:"strong"`
+}
+
+// DeltaOpType the type of the delta instruction
+type DeltaOpType string
+
+// DeltaOp the delta instruction
+type DeltaOp struct {
+	// Type the type of the delta instruction
+	Type DeltaOpType `json:"type"`
+	// Offset the start position of the data to copy from the base, only for the copy instruction
+	Offset int64 `json:"offset,omitempty"`
+	// Length the length of the data
+	Length int64 `json:"length"`
+	// Data the literal data, only for the literal instruction
+	Data []byte `json:"data,omitempty"`
+}
+
+// Delta the instructions to rebuild the target data from the base data
+type Delta struct {
+	// Size the total size of the target data
+	Size int64 `json:"size"`
+	// Ops the delta instructions in order
+	Ops []*DeltaOp `json:"ops"`
+}
+
+func (d *Delta) appendCopy(offset int64, length int64) {
+	if len(d.Ops) > 0 {
+		last := d.Ops[len(d.Ops)-1]
+		if last.Type == DeltaOpCopy && last.Offset+last.Length == offset {
+			last.Length += length
+			d.Size += length
+			return
+		}
+	}
+	d.Ops = append(d.Ops, &DeltaOp{Type: DeltaOpCopy, Offset: offset, Length: length})
+	d.Size += length
+}
+
+func (d *Delta) appendLiteral(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	d.Ops = append(d.Ops, &DeltaOp{Type: DeltaOpLiteral, Length: int64(len(data)), Data: bytes.Clone(data)})
+	d.Size += int64(len(data))
+}
+
+// GenerateSignature generate the rsync-style signature of the base data, every block of the data
+// contains a rolling checksum and a strong hash value that is calculated by the h
+func GenerateSignature(h Hash, base io.Reader, blockSize int) (sig *Signature, err error) {
+	return GenerateSignatureContext(context.Background(), h, base, blockSize)
+}
+
+// GenerateSignatureContext is like GenerateSignature but returns the ctx.Err() when the ctx is done
+func GenerateSignatureContext(ctx context.Context, h Hash, base io.Reader, blockSize int) (sig *Signature, err error) {
+	if h == nil {
+		return nil, errNilHash
+	}
+	if base == nil {
+		return nil, errNilFile
+	}
+	if blockSize <= 0 {
+		return nil, errBlockSizeInvalid
+	}
+	if err = checkTextEncoding(h); err != nil {
+		return nil, err
+	}
+	sig = &Signature{
+		Algorithm: h.Algorithm(),
+		BlockSize: blockSize,
+	}
+	block := make([]byte, blockSize)
+	for {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := io.ReadFull(base, block)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		strong := h.Hash(block[:n])
+		if err = checkTextHash(strong); err != nil {
+			return nil, err
+		}
+		sig.Blocks = append(sig.Blocks, &BlockSignature{
+			Index:  len(sig.Blocks),
+			Weak:   adler32.Checksum(block[:n]),
+			Strong: strong,
+		})
+		sig.Size += int64(n)
+		if n < blockSize {
+			break
+		}
+	}
+	return sig, nil
+}
+
+// GenerateDelta generate the copy and literal instructions to rebuild the target data from the base data of the
+// signature, the signature must be generated by the same hash algorithm as the h
+func GenerateDelta(h Hash, sig *Signature, target io.Reader) (delta *Delta, err error) {
+	return GenerateDeltaContext(context.Background(), h, sig, target)
+}
+
+// GenerateDeltaContext is like GenerateDelta but returns the ctx.Err() when the ctx is done
+func GenerateDeltaContext(ctx context.Context, h Hash, sig *Signature, target io.Reader) (delta *Delta, err error) {
+	if h == nil {
+		return nil, errNilHash
+	}
+	if sig == nil {
+		return nil, errNilSignature
+	}
+	if target == nil {
+		return nil, errNilFile
+	}
+	if sig.BlockSize <= 0 {
+		return nil, errBlockSizeInvalid
+	}
+	if sig.Algorithm != h.Algorithm() {
+		return nil, fmt.Errorf("the signature is generated by another hash algorithm => %s", sig.Algorithm)
+	}
+
+	bs := sig.BlockSize
+	// the full blocks are matched by the rolling checksum, and the short tail block is only matched at the end
+	blocks := make(map[uint32][]*BlockSignature, len(sig.Blocks))
+	var tailBlock *BlockSignature
+	tailSize := int(sig.Size % int64(bs))
+	for _, block := range sig.Blocks {
+		if tailSize > 0 && block.Index == len(sig.Blocks)-1 {
+			tailBlock = block
+			continue
+		}
+		blocks[block.Weak] = append(blocks[block.Weak], block)
+	}
+	matchBlock := func(candidates []*BlockSignature, data []byte) *BlockSignature {
+		if len(candidates) == 0 {
+			return nil
+		}
+		strong := h.Hash(data)
+		for _, block := range candidates {
+			if block.Strong == strong {
+				return block
+			}
+		}
+		return nil
+	}
+
+	delta = &Delta{}
+	buf := make([]byte, 0, max(bs*4, 64*1024))
+	// pos is the start of the current window and lit is the start of the pending literal data
+	pos, lit := 0, 0
+	isEOF := false
+	var rc *RollingChecksum
+	for {
+		// keep one more byte after the window to roll forward
+		if len(buf)-pos <= bs && !isEOF {
+			if err = ctx.Err(); err != nil {
+				return nil, err
+			}
+			if pos-lit >= maxDeltaLiteralSize {
+				delta.appendLiteral(buf[lit:pos])
+				lit = pos
+			}
+			// move the pending data to the front of the buffer
+			buf = buf[:copy(buf, buf[lit:])]
+			pos -= lit
+			lit = 0
+			if cap(buf)-len(buf) <= bs {
+				buf = append(make([]byte, 0, cap(buf)*2), buf...)
+			}
+			for len(buf)-pos <= bs && !isEOF {
+				n, err := target.Read(buf[len(buf):cap(buf)])
+				buf = buf[:len(buf)+n]
+				if err == io.EOF {
+					isEOF = true
+				} else if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		remain := len(buf) - pos
+		if remain < bs {
+			// only the short tail block can be matched at the end of the target
+			if tailBlock != nil && remain == tailSize && adler32.Checksum(buf[pos:]) == tailBlock.Weak && matchBlock([]*BlockSignature{tailBlock}, buf[pos:]) != nil {
+				delta.appendLiteral(buf[lit:pos])
+				delta.appendCopy(int64(tailBlock.Index)*int64(bs), int64(remain))
+				lit = len(buf)
+			}
+			delta.appendLiteral(buf[lit:])
+			break
+		}
+
+		window := buf[pos : pos+bs]
+		if rc == nil {
+			rc = NewRollingChecksum(window)
+		}
+		if block := matchBlock(blocks[rc.Sum32()], window); block != nil {
+			delta.appendLiteral(buf[lit:pos])
+			delta.appendCopy(int64(block.Index)*int64(bs), int64(bs))
+			pos += bs
+			lit = pos
+			rc = nil
+			continue
+		}
+		if pos+bs < len(buf) {
+			rc.Roll(buf[pos], buf[pos+bs])
+		} else {
+			// reach the end of the target, the window is shrinking
+			rc = nil
+		}
+		pos++
+	}
+	return delta, nil
+}
+
+// ApplyDelta rebuild the target data from the base data with the delta instructions, and write it to the w
+func ApplyDelta(base io.ReaderAt, delta *Delta, w io.Writer) error {
+	if delta == nil {
+		return errNilDelta
+	}
+	if base == nil {
+		return errNilFile
+	}
+	var size int64
+	for _, op := range delta.Ops {
+		switch op.Type {
+		case DeltaOpCopy:
+			n, err := io.Copy(w, io.NewSectionReader(base, op.Offset, op.Length))
+			if err != nil {
+				return err
+			}
+			if n != op.Length {
+				return io.ErrUnexpectedEOF
+			}
+		case DeltaOpLiteral:
+			if int64(len(op.Data)) != op.Length {
+				return errDeltaSizeMismatch
+			}
+			if _, err := w.Write(op.Data); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported delta instruction => %s", op.Type)
+		}
+		size += op.Length
+	}
+	if size != delta.Size {
+		return errDeltaSizeMismatch
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package hashutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateDelta(t *testing.T) {
+	base, err := os.ReadFile(testFilePath)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	half := len(base) / 2
+
+	testCases := []struct {
+		name      string
+		target    []byte
+		blockSize int
+		reuseBase bool
+	}{
+		{"the same data", base, 64, true},
+		{"insert one byte at the start", append([]byte{'x'}, base...), 64, true},
+		{"delete one byte at the start", base[1:], 64, true},
+		{"modify one byte in the middle", append(append(bytes.Clone(base[:half]), 'x'), base[half+1:]...), 64, true},
+		{"append data", append(bytes.Clone(base), "hello gopher"...), 64, true},
+		{"empty target", nil, 64, true},
+		{"block size larger than the data", base, len(base) * 2, true},
+		{"unrelated data", bytes.Repeat([]byte("gopher"), 1000), 64, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sig, err := GenerateSignature(testHash, bytes.NewReader(base), tc.blockSize)
+			if err != nil {
+				t.Errorf("generate signature error => %v", err)
+				return
+			}
+			if sig.Size != int64(len(base)) {
+				t.Errorf("generate signature error, expect the size %d but actual get %d", len(base), sig.Size)
+			}
+			delta, err := GenerateDelta(testHash, sig, bytes.NewReader(tc.target))
+			if err != nil {
+				t.Errorf("generate delta error => %v", err)
+				return
+			}
+
+			var literal int64
+			for _, op := range delta.Ops {
+				if op.Type == DeltaOpLiteral {
+					literal += op.Length
+				}
+			}
+			if tc.reuseBase && literal > int64(tc.blockSize*2) && len(tc.target) > tc.blockSize {
+				t.Errorf("generate delta error, expect to reuse the base data, but get %d bytes literal data", literal)
+			}
+
+			patched := new(bytes.Buffer)
+			if err = ApplyDelta(bytes.NewReader(base), delta, patched); err != nil {
+				t.Errorf("apply delta error => %v", err)
+				return
+			}
+			if !bytes.Equal(patched.Bytes(), tc.target) {
+				t.Errorf("apply delta error, the patched data is not equal to the target")
+			}
+		})
+	}
+}
+
+func TestGenerateDelta_LongLiteral(t *testing.T) {
+	base := bytes.Repeat([]byte("hello gopher"), 100)
+	target := append(bytes.Repeat([]byte{'x'}, maxDeltaLiteralSize*2+10), base...)
+	sig, err := GenerateSignature(testHash, bytes.NewReader(base), 128)
+	if err != nil {
+		t.Errorf("generate signature error => %v", err)
+		return
+	}
+	delta, err := GenerateDelta(testHash, sig, bytes.NewReader(target))
+	if err != nil {
+		t.Errorf("generate delta error => %v", err)
+		return
+	}
+	for _, op := range delta.Ops {
+		if op.Type == DeltaOpLiteral && op.Length > maxDeltaLiteralSize*2 {
+			t.Errorf("generate delta error, the literal data is too large => %d", op.Length)
+		}
+	}
+	patched := new(bytes.Buffer)
+	if err = ApplyDelta(bytes.NewReader(base), delta, patched); err != nil {
+		t.Errorf("apply delta error => %v", err)
+		return
+	}
+	if !bytes.Equal(patched.Bytes(), target) {
+		t.Errorf("apply delta error, the patched data is not equal to the target")
+	}
+}
+
+func TestGenerateSignature_ReturnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := GenerateSignature(testHash, nil, 64); err == nil {
+		t.Errorf("test GenerateSignature error, expect to get an error with nil reader but get nil")
+	}
+	if _, err := GenerateSignature(testHash, strings.NewReader("hello"), 0); err == nil {
+		t.Errorf("test GenerateSignature error, expect to get an error with zero block size but get nil")
+	}
+	if _, err := GenerateSignatureContext(ctx, testHash, strings.NewReader("hello"), 64); !errors.Is(err, context.Canceled) {
+		t.Errorf("test GenerateSignatureContext error, expect to get error %v, but actual get %v", context.Canceled, err)
+	}
+}
+
+func TestGenerateDelta_ReturnError(t *testing.T) {
+	sig, err := GenerateSignature(testHash, strings.NewReader("hello gopher"), 4)
+	if err != nil {
+		t.Errorf("generate signature error => %v", err)
+		return
+	}
+	sha1Hash, err := NewHash(SHA1Hash)
+	if err != nil {
+		t.Errorf("init hash component error => %v", err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name string
+		fn   func() error
+	}{
+		{"nil signature", func() error {
+			_, err := GenerateDelta(testHash, nil, strings.NewReader("hello"))
+			return err
+		}},
+		{"nil target", func() error {
+			_, err := GenerateDelta(testHash, sig, nil)
+			return err
+		}},
+		{"invalid block size", func() error {
+			_, err := GenerateDelta(testHash, &Signature{Algorithm: DefaultHash}, strings.NewReader("hello"))
+			return err
+		}},
+		{"different algorithm", func() error {
+			_, err := GenerateDelta(sha1Hash, sig, strings.NewReader("hello"))
+			return err
+		}},
+		{"canceled context", func() error {
+			_, err := GenerateDeltaContext(ctx, testHash, sig, strings.NewReader("hello"))
+			return err
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.fn(); err == nil {
+				t.Errorf("test GenerateDelta error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestApplyDelta_ReturnError(t *testing.T) {
+	base := bytes.NewReader([]byte("hello gopher"))
+	testCases := []struct {
+		name  string
+		base  *bytes.Reader
+		delta *Delta
+	}{
+		{"nil delta", base, nil},
+		{"nil base", nil, &Delta{}},
+		{"copy out of range", base, &Delta{Size: 10, Ops: []*DeltaOp{{Type: DeltaOpCopy, Offset: 8, Length: 10}}}},
+		{"invalid literal", base, &Delta{Size: 10, Ops: []*DeltaOp{{Type: DeltaOpLiteral, Length: 10, Data: []byte("hello")}}}},
+		{"unsupported instruction", base, &Delta{Size: 1, Ops: []*DeltaOp{{Type: "unknown", Length: 1}}}},
+		{"size mismatch", base, &Delta{Size: 10, Ops: []*DeltaOp{{Type: DeltaOpCopy, Offset: 0, Length: 5}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			if tc.base == nil {
+				err = ApplyDelta(nil, tc.delta, new(bytes.Buffer))
+			} else {
+				err = ApplyDelta(tc.base, tc.delta, new(bytes.Buffer))
+			}
+			if err == nil {
+				t.Errorf("test ApplyDelta error, expect to get an error but get nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package hashutil
+
+import (
+	"crypto/hmac"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// EncodingHex the lowercase hex encoding, it is the default encoding
+	EncodingHex Encoding = iota
+	// EncodingRaw the raw bytes of the hash value without encoding
+	EncodingRaw
+	// EncodingBase64 the standard base64 encoding with padding, like the Content-MD5 header
+	EncodingBase64
+	// EncodingBase64URL the URL-safe base64 encoding with padding
+	EncodingBase64URL
+	// EncodingBase32 the standard base32 encoding with padding
+	EncodingBase32
+	// EncodingSRI the Subresource Integrity format, the algorithm name and the standard base64 encoding
+	// joined by a hyphen, like sha256-<base64>
+	EncodingSRI
+	// EncodingMultihash the hex encoding of the multihash that is prefixed with the varint code of the algorithm
+	// and the length of the hash value, only supports the algorithms with a multihash code
+	EncodingMultihash
+)
+
+var (
+	errInvalidMultihash       = errors.New("invalid multihash")
+	errRawEncodingUnsupported = errors.New("the EncodingRaw is unsupported, the hash values are stored in the JSON that requires a text encoding")
+)
+
+// textEncodings the encodings to detect the encoding of the hash value in order, the EncodingRaw is excluded
+// because any string can be decoded as the raw bytes
+var textEncodings = []Encoding{EncodingHex, EncodingBase64, EncodingBase64URL, EncodingBase32, EncodingSRI, EncodingMultihash}
+
+// multihashCodes the multicodec codes of the hash algorithms
+var multihashCodes = map[string]uint64{
+	MD5Hash:        0xd5,
+	SHA1Hash:       0x11,
+	SHA224Hash:     0x1013,
+	SHA256Hash:     0x12,
+	SHA384Hash:     0x20,
+	SHA512Hash:     0x13,
+	SHA512_256Hash: 0x1015,
+	SHA3_256Hash:   0x16,
+	SHA3_512Hash:   0x14,
+	BLAKE2b256Hash: 0xb220,
+	BLAKE2b512Hash: 0xb240,
+	BLAKE2s256Hash: 0xb260,
+}
+
+// Encoding the encoding of the hash value
+type Encoding int
+
+// EncodeHash encode the hash value of the specified hash algorithm with the encoding
+func EncodeHash(algorithm string, sum []byte, encoding Encoding) (string, error) {
+	switch encoding {
+	case EncodingHex:
+		return hex.EncodeToString(sum), nil
+	case EncodingRaw:
+		return string(sum), nil
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(sum), nil
+	case EncodingBase64URL:
+		return base64.URLEncoding.EncodeToString(sum), nil
+	case EncodingBase32:
+		return base32.StdEncoding.EncodeToString(sum), nil
+	case EncodingSRI:
+		return strings.ToLower(algorithm) + "-" + base64.StdEncoding.EncodeToString(sum), nil
+	case EncodingMultihash:
+		code, err := getMultihashCode(algorithm)
+		if err != nil {
+			return "", err
+		}
+		mh := binary.AppendUvarint(nil, code)
+		mh = binary.AppendUvarint(mh, uint64(len(sum)))
+		return hex.EncodeToString(append(mh, sum...)), nil
+	}
+	return "", fmt.Errorf("unsupported hash encoding => %d", encoding)
+}
+
+// DecodeHash decode the hash value of the specified hash algorithm that is encoded with the encoding, returns the raw
+// bytes of the hash value. The hex encoding is case-insensitive and the padding of the base64 and base32 is optional
+func DecodeHash(algorithm string, s string, encoding Encoding) ([]byte, error) {
+	switch encoding {
+	case EncodingHex:
+		return hex.DecodeString(s)
+	case EncodingRaw:
+		return []byte(s), nil
+	case EncodingBase64:
+		return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+	case EncodingBase64URL:
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	case EncodingBase32:
+		return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(strings.ToUpper(s), "="))
+	case EncodingSRI:
+		i := strings.LastIndex(s, "-")
+		if i < 0 || !strings.EqualFold(s[:i], algorithm) {
+			return nil, fmt.Errorf("the SRI hash does not match the hash algorithm => %s", algorithm)
+		}
+		return DecodeHash(algorithm, s[i+1:], EncodingBase64)
+	case EncodingMultihash:
+		code, err := getMultihashCode(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		mh, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		actualCode, n := binary.Uvarint(mh)
+		if n <= 0 || actualCode != code {
+			return nil, errInvalidMultihash
+		}
+		mh = mh[n:]
+		length, n := binary.Uvarint(mh)
+		if n <= 0 || length != uint64(len(mh)-n) {
+			return nil, errInvalidMultihash
+		}
+		return mh[n:], nil
+	}
+	return nil, fmt.Errorf("unsupported hash encoding => %d", encoding)
+}
+
+func getMultihashCode(algorithm string) (uint64, error) {
+	code, ok := multihashCodes[strings.ToLower(algorithm)]
+	if !ok {
+		return 0, fmt.Errorf("the hash algorithm has no multihash code => %s", algorithm)
+	}
+	return code, nil
+}
+
+// encode encode the hash value with the encoding of the hash, the encoding is validated when creating the hash
+func (dh *defaultHash) encode(sum []byte) string {
+	s, _ := EncodeHash(dh.algorithm, sum, dh.encoding)
+	return s
+}
+
+// decodeHash decode the hash value s with the encoding of the hash, if failed or the size of the result mismatches,
+// detect the encoding of the s from the textEncodings by the size of the raw hash value
+func (dh *defaultHash) decodeHash(s string, size int) ([]byte, bool) {
+	if sum, err := DecodeHash(dh.algorithm, s, dh.encoding); err == nil && len(sum) == size {
+		return sum, true
+	}
+	for _, encoding := range textEncodings {
+		if sum, err := DecodeHash(dh.algorithm, s, encoding); err == nil && len(sum) == size {
+			return sum, true
+		}
+	}
+	return nil, false
+}
+
+// matchHash whether the encoded hash value s equals the raw hash value sum, the s can be in any of the encodings,
+// see decodeHash. The values are compared in constant time, so the comparison of the keyed hash values does not
+// leak how many leading bytes are matched
+func (dh *defaultHash) matchHash(s string, sum []byte) bool {
+	expect, ok := dh.decodeHash(s, len(sum))
+	return ok && hmac.Equal(expect, sum)
+}
+
+// equalHash whether the two encoded hash values are equal, even if they are in different encodings, cases or paddings,
+// the values are compared in constant time like matchHash
+func (dh *defaultHash) equalHash(a string, b string) bool {
+	sum, ok := dh.decodeHash(b, dh.new().Size())
+	return ok && dh.matchHash(a, sum)
+}
+
+// checkTextEncoding returns the errRawEncodingUnsupported if the hash uses the EncodingRaw, it is for the APIs
+// that return the hash values stored in the JSON, the raw bytes are usually invalid UTF-8 and corrupted by the JSON
+func (dh *defaultHash) checkTextEncoding() error {
+	if dh.encoding == EncodingRaw {
+		return errRawEncodingUnsupported
+	}
+	return nil
+}
+
+// checkTextEncoding is like the defaultHash.checkTextEncoding, but also accepts the Hash that is not created by this
+// package, whose encoding is unknown, so its hash values need to be checked by checkTextHash one by one
+func checkTextEncoding(h Hash) error {
+	if dh, ok := h.(*defaultHash); ok {
+		return dh.checkTextEncoding()
+	}
+	return nil
+}
+
+// checkTextHash returns the errRawEncodingUnsupported if the hash value is invalid UTF-8 and can't be stored in the JSON
+func checkTextHash(hash string) error {
+	if !utf8.ValidString(hash) {
+		return errRawEncodingUnsupported
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package hashutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestEncodeHash(t *testing.T) {
+	sha256Sum, _ := hex.DecodeString("f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c")
+	blake2bSum, _ := hex.DecodeString("262aaf7f3c96f3eec22f08acbf2f23a6de3ae09af6aed7271ad9efc10ca438c6")
+	testCases := []struct {
+		name      string
+		algorithm string
+		sum       []byte
+		encoding  Encoding
+		expect    string
+	}{
+		{"hex", SHA256Hash, sha256Sum, EncodingHex, "f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c"},
+		{"raw", SHA256Hash, sha256Sum, EncodingRaw, string(sha256Sum)},
+		{"base64", SHA256Hash, sha256Sum, EncodingBase64, "9zksT0DrMtIebcCHoABJ6RT2zmm3YnH0byuRxT81Fmw="},
+		{"base64 url", SHA256Hash, []byte{0xfb, 0xff}, EncodingBase64URL, "-_8="},
+		{"base32", SHA256Hash, sha256Sum, EncodingBase32, "644SYT2A5MZNEHTNYCD2AACJ5EKPNTTJW5RHD5DPFOI4KPZVCZWA===="},
+		{"sri", SHA256Hash, sha256Sum, EncodingSRI, "sha256-9zksT0DrMtIebcCHoABJ6RT2zmm3YnH0byuRxT81Fmw="},
+		{"multihash sha256", SHA256Hash, sha256Sum, EncodingMultihash, "1220f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c"},
+		{"multihash blake2b-256", BLAKE2b256Hash, blake2bSum, EncodingMultihash, "a0e40220262aaf7f3c96f3eec22f08acbf2f23a6de3ae09af6aed7271ad9efc10ca438c6"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := EncodeHash(tc.algorithm, tc.sum, tc.encoding)
+			if err != nil {
+				t.Errorf("test EncodeHash error => %v", err)
+				return
+			}
+			if actual != tc.expect {
+				t.Errorf("test EncodeHash error, expect to get %q, but actual get %q", tc.expect, actual)
+				return
+			}
+			sum, err := DecodeHash(tc.algorithm, actual, tc.encoding)
+			if err != nil {
+				t.Errorf("test DecodeHash error => %v", err)
+				return
+			}
+			if !bytes.Equal(sum, tc.sum) {
+				t.Errorf("test DecodeHash error, expect to get %x, but actual get %x", tc.sum, sum)
+			}
+		})
+	}
+}
+
+func TestDecodeHash(t *testing.T) {
+	expect, _ := hex.DecodeString("f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c")
+	testCases := []struct {
+		name     string
+		s        string
+		encoding Encoding
+	}{
+		{"upper case hex", "F7392C4F40EB32D21E6DC087A00049E914F6CE69B76271F46F2B91C53F35166C", EncodingHex},
+		{"base64 without padding", "9zksT0DrMtIebcCHoABJ6RT2zmm3YnH0byuRxT81Fmw", EncodingBase64},
+		{"lower case base32 without padding", "644syt2a5mznehtnycd2aacj5ekpnttjw5rhd5dpfoi4kpzvczwa", EncodingBase32},
+		{"upper case sri algorithm", "SHA256-9zksT0DrMtIebcCHoABJ6RT2zmm3YnH0byuRxT81Fmw=", EncodingSRI},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := DecodeHash(SHA256Hash, tc.s, tc.encoding)
+			if err != nil {
+				t.Errorf("test DecodeHash error => %v", err)
+				return
+			}
+			if !bytes.Equal(actual, expect) {
+				t.Errorf("test DecodeHash error, expect to get %x, but actual get %x", expect, actual)
+			}
+		})
+	}
+}
+
+func TestDecodeHash_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		algorithm string
+		s         string
+		encoding  Encoding
+	}{
+		{"invalid hex", SHA256Hash, "xyz", EncodingHex},
+		{"invalid base64", SHA256Hash, "!!", EncodingBase64},
+		{"sri with another algorithm", SHA256Hash, "sha512-9zksT0DrMtIebcCHoABJ6RT2zmm3YnH0byuRxT81Fmw=", EncodingSRI},
+		{"sri without algorithm", SHA256Hash, "9zksT0DrMtIebcCHoABJ6RT2zmm3YnH0byuRxT81Fmw=", EncodingSRI},
+		{"multihash with another code", SHA256Hash, "1320f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c", EncodingMultihash},
+		{"multihash with wrong length", SHA256Hash, "1221f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c", EncodingMultihash},
+		{"multihash without code", CRC32Hash, "0400000000", EncodingMultihash},
+		{"unsupported encoding", SHA256Hash, "", Encoding(-1)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeHash(tc.algorithm, tc.s, tc.encoding)
+			if err == nil {
+				t.Errorf("test DecodeHash error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestNewHash_WithEncoding(t *testing.T) {
+	h, err := NewHash(SHA256Hash, WithEncoding(EncodingSRI))
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	expect := "sha256-9zksT0DrMtIebcCHoABJ6RT2zmm3YnH0byuRxT81Fmw="
+	if actual := h.HashFromString("hello gopher"); actual != expect {
+		t.Errorf("test WithEncoding error, expect to get %s, but actual get %s", expect, actual)
+	}
+
+	if _, err = NewHash(CRC32Hash, WithEncoding(EncodingMultihash)); err == nil {
+		t.Errorf("test WithEncoding error, expect to get an error with the algorithm without multihash code")
+	}
+	if _, err = NewHash(SHA256Hash, WithEncoding(Encoding(-1))); err == nil {
+		t.Errorf("test WithEncoding error, expect to get an error with the unsupported encoding")
+	}
+}
+
+func TestCompareHashValues_WithEncoding(t *testing.T) {
+	testCases := []struct {
+		name     string
+		encoding Encoding
+	}{
+		{"raw", EncodingRaw},
+		{"base64", EncodingBase64},
+		{"base64 url", EncodingBase64URL},
+		{"base32", EncodingBase32},
+		{"sri", EncodingSRI},
+		{"multihash", EncodingMultihash},
+	}
+
+	stat, err := os.Stat(testFilePath)
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+	sha256Hash, err := NewHash(SHA256Hash)
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	expect, err := sha256Hash.HashFromFileName(testFilePath)
+	if err != nil {
+		t.Errorf("test HashFromFileName error => %v", err)
+		return
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := NewHash(SHA256Hash, WithEncoding(tc.encoding))
+			if err != nil {
+				t.Errorf("NewHash error => %v", err)
+				return
+			}
+			hvs, err := h.CheckpointsHashFromFileName(testFilePath, 100, 5)
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+				return
+			}
+			sum, err := DecodeHash(SHA256Hash, hvs.Last().Hash, tc.encoding)
+			if err != nil {
+				t.Errorf("test DecodeHash error => %v", err)
+				return
+			}
+			if actual := hex.EncodeToString(sum); actual != expect {
+				t.Errorf("test CheckpointsHashFromFileName error, expect to get %s after decoding, but actual get %s", expect, actual)
+			}
+			if equal, _ := h.CompareHashValues(testFilePath, stat.Size(), hvs.Last().Hash, 100, hvs); !equal {
+				t.Errorf("test CompareHashValues error, expect the file is equal with the %s encoding", tc.name)
+			}
+		})
+	}
+}
+
+func TestVerifyMerkleProof_WithEncoding(t *testing.T) {
+	h, err := NewHash(SHA256Hash, WithEncoding(EncodingBase64URL))
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	data := []byte("hello gopher, hello merkle tree")
+	tree, err := MerkleTreeFromReaderAt(h, bytes.NewReader(data), int64(len(data)), 4, 0)
+	if err != nil {
+		t.Errorf("test MerkleTreeFromReaderAt error => %v", err)
+		return
+	}
+	proof, err := tree.Proof(3)
+	if err != nil {
+		t.Errorf("test Proof error => %v", err)
+		return
+	}
+	if !VerifyMerkleProof(h, tree.Root, data[12:16], proof) {
+		t.Errorf("test VerifyMerkleProof error, expect the proof is valid")
+	}
+}
+
+func TestCompareHashValues_WithOtherEncoding(t *testing.T) {
+	stat, err := os.Stat(testFilePath)
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+	h, err := NewHash(SHA256Hash)
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	testCases := []struct {
+		name     string
+		encoding Encoding
+	}{
+		{"base64", EncodingBase64},
+		{"base64 url", EncodingBase64URL},
+		{"base32", EncodingBase32},
+		{"sri", EncodingSRI},
+		{"multihash", EncodingMultihash},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			other, err := NewHash(SHA256Hash, WithEncoding(tc.encoding))
+			if err != nil {
+				t.Errorf("NewHash error => %v", err)
+				return
+			}
+			hvs, err := other.CheckpointsHashFromFileName(testFilePath, 100, 5)
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+				return
+			}
+			if equal, _ := h.CompareHashValues(testFilePath, stat.Size(), hvs.Last().Hash, 100, hvs); !equal {
+				t.Errorf("test CompareHashValues error, expect the file is equal with the hash values in the %s encoding", tc.name)
+			}
+		})
+	}
+}
+
+func TestRawEncoding_ReturnError(t *testing.T) {
+	h, err := NewHash(SHA256Hash, WithEncoding(EncodingRaw))
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	data := []byte("hello gopher")
+	testCases := []struct {
+		name string
+		fn   func() error
+	}{
+		{"HashDir", func() error {
+			_, err := HashDir(h, t.TempDir(), ManifestOptions{})
+			return err
+		}},
+		{"ChunksHashFromReaderAt", func() error {
+			_, err := ChunksHashFromReaderAt(h, bytes.NewReader(data), int64(len(data)), 4, 0)
+			return err
+		}},
+		{"MerkleTreeFromReaderAt", func() error {
+			_, err := MerkleTreeFromReaderAt(h, bytes.NewReader(data), int64(len(data)), 4, 0)
+			return err
+		}},
+		{"ResumableCheckpointsHashFromFile", func() error {
+			f, err := os.Open(testFilePath)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, _, err = ResumableCheckpointsHashFromFile(h, f, 100, 5)
+			return err
+		}},
+		{"GenerateSignature", func() error {
+			_, err := GenerateSignature(h, bytes.NewReader(data), 4)
+			return err
+		}},
+		{"GenerateSignature with custom hash", func() error {
+			_, err := GenerateSignature(customHash{h}, bytes.NewReader(data), 4)
+			return err
+		}},
+		{"ContentDefinedChunks", func() error {
+			_, err := ContentDefinedChunks(h, bytes.NewReader(data), DefaultCDCOptions())
+			return err
+		}},
+		{"ContentDefinedChunks with custom hash", func() error {
+			_, err := ContentDefinedChunks(customHash{h}, bytes.NewReader(data), DefaultCDCOptions())
+			return err
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.fn(); !errors.Is(err, errRawEncodingUnsupported) {
+				t.Errorf("test %s error, expect to get error %v but get %v", tc.name, errRawEncodingUnsupported, err)
+			}
+		})
+	}
+}
@@ -5,33 +5,107 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"errors"
 	"fmt"
 	"hash"
 	"hash/adler32"
 	"hash/crc32"
 	"hash/crc64"
 	"hash/fnv"
+	"sort"
 	"strings"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/zeebo/xxh3"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/sha3"
 )
 
 var (
-	factories map[string]hashFactory
+	factories   map[string]hashFactory
+	factoriesMu sync.RWMutex
+
+	errEmptyAlgorithm = errors.New("hash algorithm can't be empty")
+	errNilHashFactory = errors.New("hash factory can't be nil")
 )
 
 type hashFactory func() hash.Hash
 
 // NewHash return the hash implementation by the specified hash algorithm
-func NewHash(algorithm string) (Hash, error) {
+func NewHash(algorithm string, opts ...Option) (Hash, error) {
 	f, err := getFactory(algorithm)
 	if err != nil {
 		return nil, err
 	}
-	return &defaultHash{factory: f}, nil
+	return newHash(strings.ToLower(algorithm), f, opts...)
+}
+
+func newHash(algorithm string, f hashFactory, opts ...Option) (*defaultHash, error) {
+	dh := &defaultHash{
+		algorithm: algorithm,
+		factory:   f,
+		progress:  nopProgress{},
+	}
+	for _, opt := range opts {
+		opt(dh)
+	}
+	// validate the encoding
+	if _, err := EncodeHash(dh.algorithm, nil, dh.encoding); err != nil {
+		return nil, err
+	}
+	return dh, nil
+}
+
+// RegisterHash register a custom hash algorithm, then NewHash can resolve it by the name.
+// The name is case-insensitive and must not be registered already, it is safe for concurrent use
+func RegisterHash(name string, factory func() hash.Hash) error {
+	name = strings.ToLower(name)
+	if len(name) == 0 {
+		return errEmptyAlgorithm
+	}
+	if factory == nil {
+		return errNilHashFactory
+	}
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, ok := factories[name]; ok {
+		return fmt.Errorf("hash algorithm is already registered => %s", name)
+	}
+	factories[name] = factory
+	return nil
+}
+
+// Unregister remove the registered hash algorithm, include the builtin algorithms, it is safe for concurrent use
+func Unregister(name string) error {
+	name = strings.ToLower(name)
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, ok := factories[name]; !ok {
+		return fmt.Errorf("unsupported hash algorithm => %s", name)
+	}
+	delete(factories, name)
+	return nil
+}
+
+// ListAlgorithms returns the sorted names of all the registered hash algorithms
+func ListAlgorithms() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	algorithms := make([]string, 0, len(factories))
+	for name := range factories {
+		algorithms = append(algorithms, name)
+	}
+	sort.Strings(algorithms)
+	return algorithms
 }
 
 func getFactory(algorithm string) (hashFactory, error) {
 	algorithm = strings.ToLower(algorithm)
+	factoriesMu.RLock()
 	f, ok := factories[algorithm]
+	factoriesMu.RUnlock()
 	if ok {
 		return f, nil
 	}
@@ -45,10 +119,26 @@ const (
 	MD5Hash = "md5"
 	// SHA1Hash the SHA-1 hash algorithm
 	SHA1Hash = "sha1"
+	// SHA224Hash the SHA-224 hash algorithm
+	SHA224Hash = "sha224"
 	// SHA256Hash the SHA256 hash algorithm
 	SHA256Hash = "sha256"
+	// SHA384Hash the SHA-384 hash algorithm
+	SHA384Hash = "sha384"
 	// SHA512Hash the SHA-512 hash algorithm
 	SHA512Hash = "sha512"
+	// SHA512_256Hash the SHA-512/256 hash algorithm
+	SHA512_256Hash = "sha512-256"
+	// SHA3_256Hash the SHA3-256 hash algorithm
+	SHA3_256Hash = "sha3-256"
+	// SHA3_512Hash the SHA3-512 hash algorithm
+	SHA3_512Hash = "sha3-512"
+	// BLAKE2b256Hash the BLAKE2b-256 hash algorithm
+	BLAKE2b256Hash = "blake2b-256"
+	// BLAKE2b512Hash the BLAKE2b-512 hash algorithm
+	BLAKE2b512Hash = "blake2b-512"
+	// BLAKE2s256Hash the BLAKE2s-256 hash algorithm
+	BLAKE2s256Hash = "blake2s-256"
 	// CRC32Hash the CRC-32 checksum
 	CRC32Hash = "crc32"
 	// CRC64Hash the CRC-64 checksum
@@ -67,14 +157,38 @@ const (
 	FNV1128Hash = "fnv-1-128"
 	// FNV1A128Hash the 128-bit FNV-1a non-cryptographic hash function
 	FNV1A128Hash = "fnv-1a-128"
+	// XXH64Hash the 64-bit xxHash non-cryptographic hash function
+	XXH64Hash = "xxh64"
+	// XXH3Hash the 64-bit XXH3 non-cryptographic hash function
+	XXH3Hash = "xxh3"
+	// XXH128Hash the 128-bit XXH3 non-cryptographic hash function
+	XXH128Hash = "xxh128"
 )
 
 func register() {
 	factories = map[string]hashFactory{
-		MD5Hash:    md5.New,
-		SHA1Hash:   sha1.New,
-		SHA256Hash: sha256.New,
-		SHA512Hash: sha512.New,
+		MD5Hash:        md5.New,
+		SHA1Hash:       sha1.New,
+		SHA224Hash:     sha256.New224,
+		SHA256Hash:     sha256.New,
+		SHA384Hash:     sha512.New384,
+		SHA512Hash:     sha512.New,
+		SHA512_256Hash: sha512.New512_256,
+		SHA3_256Hash:   sha3.New256,
+		SHA3_512Hash:   sha3.New512,
+		BLAKE2b256Hash: func() hash.Hash {
+			// the error is always nil without a key
+			h, _ := blake2b.New256(nil)
+			return h
+		},
+		BLAKE2b512Hash: func() hash.Hash {
+			h, _ := blake2b.New512(nil)
+			return h
+		},
+		BLAKE2s256Hash: func() hash.Hash {
+			h, _ := blake2s.New256(nil)
+			return h
+		},
 		CRC32Hash: func() hash.Hash {
 			return crc32.NewIEEE()
 		},
@@ -98,6 +212,15 @@ func register() {
 		},
 		FNV1128Hash:  fnv.New128,
 		FNV1A128Hash: fnv.New128a,
+		XXH64Hash: func() hash.Hash {
+			return xxhash.New()
+		},
+		XXH3Hash: func() hash.Hash {
+			return xxh3.New()
+		},
+		XXH128Hash: func() hash.Hash {
+			return xxh3.New128()
+		},
 	}
 }
 
@@ -1,6 +1,10 @@
 package hashutil
 
 import (
+	"crypto/sha256"
+	"hash"
+	"slices"
+	"sync"
 	"testing"
 )
 
@@ -17,8 +21,16 @@ func TestAllHashAlgorithms(t *testing.T) {
 	}{
 		{MD5Hash, input, "0e181d273c0a4593230ce8319eaae9b7"},
 		{SHA1Hash, input, "07adeacfcdde2146d26119d79cb0028d831e46e9"},
+		{SHA224Hash, input, "5f71975d07a64d26430874d889d7b872910c91d71d8798e333a2aae5"},
 		{SHA256Hash, input, "f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c"},
+		{SHA384Hash, input, "652c1abaad519a76b8ca8290e0054d1038df6b998ab82b0aa410f460734ebf6580523d1f67b346a6c9e5d7f6a07a8294"},
 		{SHA512Hash, input, "d65b9e26ebef94e8984eeb5bba2558183db4a312ca750495e427b288739eebc67ce732b234210638dabe914a9f7202b2facd248ddb753fc649de83574aa2d231"},
+		{SHA512_256Hash, input, "68f9e8115be4572df0a8f4bbbca3ae84a4b44d2f091c398ea998326c74aaebac"},
+		{SHA3_256Hash, input, "50695293c6ef6fb457568359ebcb26341399ed7351dbb227b97d63f794439ce4"},
+		{SHA3_512Hash, input, "0c1f19f861156cd537eadc368113e305828459f8c3de0224fdf9c6b4818c732b5927a3ba308452a33039a6e0ba1b625c68128aad1d25d87d3b1ef2252ae27861"},
+		{BLAKE2b256Hash, input, "262aaf7f3c96f3eec22f08acbf2f23a6de3ae09af6aed7271ad9efc10ca438c6"},
+		{BLAKE2b512Hash, input, "c48eda096289f98743576921b9275c8f6fe022eeefc896c1b74979b4a4ed772d012c0c143cde1f0637c7edcf53c0e19d2fdeb2577b459832d827f187cf9713d3"},
+		{BLAKE2s256Hash, input, "c02625f4d41e2c4631460682196a1fa7e62cd9eb263d88117a7adcd908b354ac"},
 		{CRC32Hash, input, "461b91ae"},
 		{CRC64Hash, input, "086879fc3731d9ac"},
 		{Adler32Hash, input, "1e6804ba"},
@@ -28,6 +40,9 @@ func TestAllHashAlgorithms(t *testing.T) {
 		{FNV1A64Hash, input, "d9ced1ff0e72c2cc"},
 		{FNV1128Hash, input, "af0bcdfc672247e14fc2ae047bc55b02"},
 		{FNV1A128Hash, input, "1a83a6c3193dcc0fbcc90891f7d3df14"},
+		{XXH64Hash, input, "80e0e07dc718e04d"},
+		{XXH3Hash, input, "b01dfdc6823bae34"},
+		{XXH128Hash, input, "f7df1021654488d4f4ad5993ae676926"},
 	}
 
 	for _, tc := range testCases {
@@ -45,6 +60,37 @@ func TestAllHashAlgorithms(t *testing.T) {
 	}
 }
 
+func TestAllHashAlgorithms_CheckpointsAndCompare(t *testing.T) {
+	for _, algorithm := range ListAlgorithms() {
+		t.Run(algorithm, func(t *testing.T) {
+			h, err := NewHash(algorithm)
+			if err != nil {
+				t.Errorf("init hash with [%s] algorithm error => %v", algorithm, err)
+				return
+			}
+			size, hash, hvs, err := h.GetFileSizeAndHashCheckpoints(testFilePath, 20, 10)
+			if err != nil {
+				t.Errorf("get file size and hash checkpoints with [%s] algorithm error => %v", algorithm, err)
+				return
+			}
+			if equal, _ := h.CompareHashValues(testFilePath, size, hash, 20, hvs); !equal {
+				t.Errorf("compare hash values with [%s] algorithm error, expect get true but actual get false", algorithm)
+			}
+		})
+	}
+}
+
+func TestNewHash_Algorithm(t *testing.T) {
+	h, err := NewHash("SHA256")
+	if err != nil {
+		t.Errorf("init hash component error => %v", err)
+		return
+	}
+	if actual := h.Algorithm(); actual != SHA256Hash {
+		t.Errorf("test Algorithm error, expect get %s but actual get %s", SHA256Hash, actual)
+	}
+}
+
 func TestNewHash_WithUnsupportedAlgorithm(t *testing.T) {
 	testCases := []struct {
 		algorithm string
@@ -62,6 +108,96 @@ func TestNewHash_WithUnsupportedAlgorithm(t *testing.T) {
 	}
 }
 
+func TestRegisterHash(t *testing.T) {
+	name := "Custom-SHA256"
+	if err := RegisterHash(name, sha256.New); err != nil {
+		t.Errorf("register hash error => %v", err)
+		return
+	}
+	defer Unregister(name)
+
+	if !slices.Contains(ListAlgorithms(), "custom-sha256") {
+		t.Errorf("test RegisterHash error, expect to list the custom algorithm")
+	}
+
+	h, err := NewHash(name)
+	if err != nil {
+		t.Errorf("init hash with the custom algorithm error => %v", err)
+		return
+	}
+	expect := "f7392c4f40eb32d21e6dc087a00049e914f6ce69b76271f46f2b91c53f35166c"
+	if actual := h.HashFromString("hello gopher"); actual != expect {
+		t.Errorf("calculate hash with the custom algorithm error, expect: %s, but actual: %s", expect, actual)
+	}
+}
+
+func TestRegisterHash_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		factory func() hash.Hash
+	}{
+		{"", sha256.New},
+		{"custom-nil-factory", nil},
+		{MD5Hash, sha256.New},
+		{"SHA256", sha256.New},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := RegisterHash(tc.name, tc.factory); err == nil {
+				t.Errorf("test RegisterHash error, expect to get an error, but get nil")
+			}
+		})
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	name := "custom-unregister"
+	if err := RegisterHash(name, sha256.New); err != nil {
+		t.Errorf("register hash error => %v", err)
+		return
+	}
+	if err := Unregister(name); err != nil {
+		t.Errorf("unregister hash error => %v", err)
+		return
+	}
+	if _, err := NewHash(name); err == nil {
+		t.Errorf("test Unregister error, expect to get an error after unregister, but get nil")
+	}
+	if err := Unregister(name); err == nil {
+		t.Errorf("test Unregister error, expect to get an error with unregistered algorithm, but get nil")
+	}
+}
+
+func TestRegisterHash_Concurrent(t *testing.T) {
+	name := "custom-concurrent"
+	defer Unregister(name)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	success := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if RegisterHash(name, sha256.New) == nil {
+				mu.Lock()
+				success++
+				mu.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			NewHash(DefaultHash)
+			ListAlgorithms()
+		}()
+	}
+	wg.Wait()
+	if success != 1 {
+		t.Errorf("test RegisterHash concurrently error, expect to register successfully once, but actual %d times", success)
+	}
+}
+
 func init() {
 	hash, _ := NewHash(DefaultHash)
 	testHash = hash.(*defaultHash)
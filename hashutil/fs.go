@@ -0,0 +1,133 @@
+package hashutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	errReadBackward = errors.New("the file can't seek and can only be read forward")
+)
+
+// CheckpointsHashFromFSFile calculate the hash value of the entire file and first chunk and some checkpoints like
+// Hash.CheckpointsHashFromFile, the file is read from its current offset, it can be an *os.File or a file opened
+// from any fs.FS
+func CheckpointsHashFromFSFile(h Hash, f fs.File, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	return CheckpointsHashFromFSFileContext(context.Background(), h, f, chunkSize, checkpointCount)
+}
+
+// CheckpointsHashFromFSFileContext is like CheckpointsHashFromFSFile but checks the ctx between chunks
+// and returns the ctx.Err() when the ctx is done
+func CheckpointsHashFromFSFileContext(ctx context.Context, h Hash, f fs.File, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, err
+	}
+	return dh.checkpointsHashFromFile(ctx, f, chunkSize, checkpointCount)
+}
+
+// CheckpointsHashFromReaderAt calculate the hash value of the entire data and first chunk and some checkpoints
+// like Hash.CheckpointsHashFromFile, the size is the count of bytes to read from the reader
+func CheckpointsHashFromReaderAt(h Hash, r io.ReaderAt, size int64, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	return CheckpointsHashFromReaderAtContext(context.Background(), h, r, size, chunkSize, checkpointCount)
+}
+
+// CheckpointsHashFromReaderAtContext is like CheckpointsHashFromReaderAt but checks the ctx between chunks
+// and returns the ctx.Err() when the ctx is done
+func CheckpointsHashFromReaderAtContext(ctx context.Context, h Hash, r io.ReaderAt, size int64, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, errNilFile
+	}
+	if size < 0 {
+		return nil, errSizeInvalid
+	}
+	hvs, chunkSize = buildHashValues(size, chunkSize, checkpointCount)
+	err = dh.calcHashValuesWithFile(ctx, io.NewSectionReader(r, 0, size), size, chunkSize, hvs)
+	return hvs, err
+}
+
+// isNilFile whether the file is nil, include the nil *os.File
+func isNilFile(f fs.File) bool {
+	if osFile, ok := f.(*os.File); ok {
+		return osFile == nil
+	}
+	return f == nil
+}
+
+// fileReaderAt returns the file itself if it implements the io.ReaderAt, otherwise returns an io.ReaderAt that reads the
+// file by seeking it or reading it forward from the start, like the compressed file in the zip archive.
+// The count of workers is limited to one if the file can't seek, so the chunks are read in order
+func fileReaderAt(f fs.File, workers int) (io.ReaderAt, int) {
+	if r, ok := f.(io.ReaderAt); ok {
+		return r, workers
+	}
+	if _, ok := f.(io.Seeker); !ok {
+		workers = 1
+	}
+	return &readerAt{r: f}, workers
+}
+
+// readerAt implements the io.ReaderAt with the io.Reader that may implement the io.Seeker, it is safe for concurrent use
+type readerAt struct {
+	mu sync.Mutex
+	r  io.Reader
+	// offset the offset of the next read
+	offset int64
+}
+
+func (ra *readerAt) ReadAt(p []byte, off int64) (n int, err error) {
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	if off != ra.offset {
+		if err = seekFile(ra.r, off-ra.offset, off); err != nil {
+			return 0, err
+		}
+		ra.offset = off
+	}
+	n, err = io.ReadFull(ra.r, p)
+	ra.offset += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// seekFile move the offset of the file to the specified offset, the relative is the distance from the current offset
+// to the specified offset. If the file can't seek, read and discard the data forward instead
+func seekFile(r io.Reader, relative int64, offset int64) error {
+	if s, ok := r.(io.Seeker); ok {
+		_, err := s.Seek(offset, io.SeekStart)
+		return err
+	}
+	if relative < 0 {
+		return errReadBackward
+	}
+	n, err := io.CopyN(io.Discard, r, relative)
+	if err == io.EOF && n < relative {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// joinPath join the dir and the slash-separated name, the name is converted to the local path if the WithFS option
+// is not specified, and the absolute name is returned directly
+func (dh *defaultHash) joinPath(dir string, name string) string {
+	if dh.fsys != nil {
+		return path.Join(dir, name)
+	}
+	name = filepath.FromSlash(name)
+	if len(dir) > 0 && !filepath.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+	return name
+}
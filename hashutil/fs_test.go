@@ -0,0 +1,302 @@
+package hashutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	testFSFileName = "dir/hash_test.go"
+)
+
+func TestWithFS(t *testing.T) {
+	data, err := os.ReadFile(testFilePath)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	testCases := []struct {
+		name string
+		fsys fs.FS
+	}{
+		{"MapFS", fstest.MapFS{testFSFileName: &fstest.MapFile{Data: data}}},
+		{"zip store", newTestZipFS(t, zip.Store, data)},
+		{"zip deflate", newTestZipFS(t, zip.Deflate, data)},
+	}
+
+	expectHash, _ := testHash.HashFromFileName(testFilePath)
+	expectChunk, _ := testHash.HashFromFileChunk(testFilePath, 100, 50)
+	expectHvs, _ := testHash.CheckpointsHashFromFileName(testFilePath, 100, 5)
+	expectChunks, _ := ChunksHashFromFileName(testHash, testFilePath, 100, 4)
+	expectTree, _ := MerkleTreeFromFileName(testHash, testFilePath, 100, 4)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := NewHash(DefaultHash, WithFS(tc.fsys))
+			if err != nil {
+				t.Errorf("NewHash error => %v", err)
+				return
+			}
+			if actual, err := h.HashFromFileName(testFSFileName); err != nil || actual != expectHash {
+				t.Errorf("test HashFromFileName with fs error, expect to get %s, but actual get %s, err=%v", expectHash, actual, err)
+			}
+			if actual, err := h.HashFromFileChunk(testFSFileName, 100, 50); err != nil || actual != expectChunk {
+				t.Errorf("test HashFromFileChunk with fs error, expect to get %s, but actual get %s, err=%v", expectChunk, actual, err)
+			}
+
+			hvs, err := h.CheckpointsHashFromFileName(testFSFileName, 100, 5)
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFileName with fs error => %v", err)
+				return
+			}
+			testHashValuesEqual(t, expectHvs, hvs)
+
+			f, err := tc.fsys.Open(testFSFileName)
+			if err != nil {
+				t.Errorf("open file from fs error => %v", err)
+				return
+			}
+			hvs, err = CheckpointsHashFromFSFile(h, f, 100, 5)
+			f.Close()
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFSFile error => %v", err)
+				return
+			}
+			testHashValuesEqual(t, expectHvs, hvs)
+
+			size, hash, _, err := h.GetFileSizeAndHashCheckpoints(testFSFileName, 100, 5)
+			if err != nil || size != int64(len(data)) || hash != expectHash {
+				t.Errorf("test GetFileSizeAndHashCheckpoints with fs error, expect to get %d %s, but actual get %d %s, err=%v", len(data), expectHash, size, hash, err)
+			}
+
+			chunks, err := ChunksHashFromFileName(h, testFSFileName, 100, 4)
+			if err != nil || len(chunks) != len(expectChunks) {
+				t.Errorf("test ChunksHashFromFileName with fs error, expect to get %d chunks, but actual get %d, err=%v", len(expectChunks), len(chunks), err)
+				return
+			}
+			for i, c := range chunks {
+				if *c != *expectChunks[i] {
+					t.Errorf("test ChunksHashFromFileName with fs error, expect to get %v, but actual get %v", *expectChunks[i], *c)
+				}
+			}
+
+			tree, err := MerkleTreeFromFileName(h, testFSFileName, 100, 4)
+			if err != nil || tree.Root != expectTree.Root {
+				t.Errorf("test MerkleTreeFromFileName with fs error, expect to get %s, but actual get %v, err=%v", expectTree.Root, tree, err)
+			}
+
+			result := CompareFileHashValues(h, testFSFileName, int64(len(data)), expectHash, 100, expectHvs)
+			if !result.Equal {
+				t.Errorf("test CompareFileHashValues with fs error, expect to be equal, but actual get reason=%q err=%v", result.Reason, result.Err)
+			}
+
+			report, err := VerifyChecksums(h, strings.NewReader(expectHash+"  hash_test.go\n"), "dir")
+			if err != nil || !report.OK() {
+				t.Errorf("test VerifyChecksums with fs error, expect to pass, but actual get %+v, err=%v", report, err)
+			}
+
+			m, err := HashDir(h, ".", ManifestOptions{ChunkSize: 100, CheckpointCount: 5})
+			if err != nil {
+				t.Errorf("test HashDir with fs error => %v", err)
+				return
+			}
+			testManifestPaths(t, m, []string{testFSFileName})
+			testHashValuesEqual(t, expectHvs, m.Entries[0].HashValues)
+		})
+	}
+}
+
+func TestWithFS_ResumeCheckpointsHashFromFile(t *testing.T) {
+	data, err := os.ReadFile(testFilePath)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	expect, _ := testHash.CheckpointsHashFromFileName(testFilePath, 100, 5)
+	fsys := newTestZipFS(t, zip.Deflate, data)
+	f, err := fsys.Open(testFSFileName)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, snapshot, err := ResumableCheckpointsHashFromFileContext(ctx, testHash, f, 100, 5)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("test ResumableCheckpointsHashFromFileContext error, expect to get %v, but actual get %v", context.Canceled, err)
+		return
+	}
+
+	// the file can't seek, so read the new file from the start
+	f2, err := fsys.Open(testFSFileName)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f2.Close()
+	hvs, _, err := ResumeCheckpointsHashFromFile(testHash, f2, snapshot)
+	if err != nil {
+		t.Errorf("test ResumeCheckpointsHashFromFile error => %v", err)
+		return
+	}
+	testHashValuesEqual(t, expect, hvs)
+}
+
+func TestCheckpointsHashFromReaderAt(t *testing.T) {
+	data, err := os.ReadFile(testFilePath)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	expect, _ := testHash.CheckpointsHashFromFileName(testFilePath, 100, 5)
+	hvs, err := CheckpointsHashFromReaderAt(testHash, bytes.NewReader(data), int64(len(data)), 100, 5)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromReaderAt error => %v", err)
+		return
+	}
+	testHashValuesEqual(t, expect, hvs)
+
+	// only the data before the size is hashed
+	hvs, err = CheckpointsHashFromReaderAt(testHash, bytes.NewReader(data), 10, 0, 0)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromReaderAt error => %v", err)
+		return
+	}
+	if expectHash := testHash.Hash(data[:10]); hvs.Last().Hash != expectHash {
+		t.Errorf("test CheckpointsHashFromReaderAt error, expect to get %s, but actual get %s", expectHash, hvs.Last().Hash)
+	}
+}
+
+func TestCheckpointsHashFromReaderAt_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name string
+		r    io.ReaderAt
+		size int64
+	}{
+		{"nil reader", nil, 10},
+		{"negative size", bytes.NewReader(nil), -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := CheckpointsHashFromReaderAt(testHash, tc.r, tc.size, 100, 5); err == nil {
+				t.Errorf("test CheckpointsHashFromReaderAt error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestCompareHashValuesWithReaderAt(t *testing.T) {
+	data, err := os.ReadFile(testFilePath)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	hvs, _ := testHash.CheckpointsHashFromFileName(testFilePath, 100, 5)
+	eq, err := CompareHashValuesWithReaderAt(testHash, bytes.NewReader(data), int64(len(data)), 100, hvs)
+	if err != nil || eq != hvs.Last() {
+		t.Errorf("test CompareHashValuesWithReaderAt error, expect to match all the HashValues, but actual get %v, err=%v", eq, err)
+	}
+
+	modified := bytes.Clone(data)
+	modified[len(modified)-1]++
+	eq, err = CompareHashValuesWithReaderAt(testHash, bytes.NewReader(modified), int64(len(modified)), 100, hvs)
+	if err != nil || eq != hvs[len(hvs)-2] {
+		t.Errorf("test CompareHashValuesWithReaderAt error, expect to match %v, but actual get %v, err=%v", hvs[len(hvs)-2], eq, err)
+	}
+}
+
+func TestCompareHashValuesWithReaderAt_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		r         io.ReaderAt
+		size      int64
+		chunkSize int64
+	}{
+		{"nil reader", nil, 10, 100},
+		{"negative size", bytes.NewReader(nil), -1, 100},
+		{"zero chunk size", bytes.NewReader(nil), 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := CompareHashValuesWithReaderAt(testHash, tc.r, tc.size, tc.chunkSize, nil); err == nil {
+				t.Errorf("test CompareHashValuesWithReaderAt error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestWithFS_ReturnError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":  &fstest.MapFile{Data: []byte("hello gopher")},
+		"link":   &fstest.MapFile{Data: []byte("a.txt"), Mode: fs.ModeSymlink},
+		"subdir": &fstest.MapFile{Mode: fs.ModeDir},
+	}
+	h, err := NewHash(DefaultHash, WithFS(fsys))
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	if _, err = h.HashFromFileName("/a.txt"); err == nil {
+		t.Errorf("test HashFromFileName with fs error, expect to get an error with the invalid path but get nil")
+	}
+	if _, err = h.HashFromFileName(notExistFilePath); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("test HashFromFileName with fs error, expect to get %v, but actual get %v", fs.ErrNotExist, err)
+	}
+	if _, err = HashDir(h, ".", ManifestOptions{Symlink: SymlinkRecord}); !errors.Is(err, errFSSymlinkUnsupported) {
+		t.Errorf("test HashDir with fs error, expect to get %v, but actual get %v", errFSSymlinkUnsupported, err)
+	}
+}
+
+func TestReaderAt_ReadBackward(t *testing.T) {
+	r := &readerAt{r: strings.NewReader("hello gopher")}
+	p := make([]byte, 5)
+	if n, err := r.ReadAt(p, 6); err != nil || string(p[:n]) != "gophe" {
+		t.Errorf("test ReadAt error, expect to get %q, but actual get %q, err=%v", "gophe", p[:n], err)
+	}
+	// strings.Reader can seek
+	if n, err := r.ReadAt(p, 0); err != nil || string(p[:n]) != "hello" {
+		t.Errorf("test ReadAt error, expect to get %q, but actual get %q, err=%v", "hello", p[:n], err)
+	}
+	if n, err := r.ReadAt(p, 10); err != io.EOF || string(p[:n]) != "er" {
+		t.Errorf("test ReadAt error, expect to get %q and io.EOF, but actual get %q, err=%v", "er", p[:n], err)
+	}
+
+	r = &readerAt{r: io.MultiReader(strings.NewReader("hello gopher"))}
+	if _, err := r.ReadAt(p, 6); err != nil {
+		t.Errorf("test ReadAt error => %v", err)
+	}
+	if _, err := r.ReadAt(p, 0); !errors.Is(err, errReadBackward) {
+		t.Errorf("test ReadAt error, expect to get %v, but actual get %v", errReadBackward, err)
+	}
+}
+
+func newTestZipFS(t *testing.T, method uint16, data []byte) *zip.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: testFSFileName, Method: method})
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		t.Fatalf("create zip archive error => %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open zip archive error => %v", err)
+	}
+	return zr
+}
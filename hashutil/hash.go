@@ -2,10 +2,11 @@ package hashutil
 
 import (
 	"bufio"
-	"encoding/hex"
+	"context"
 	"errors"
 	"hash"
 	"io"
+	"io/fs"
 	"os"
 	"time"
 )
@@ -14,21 +15,34 @@ var (
 	errNilFile          = errors.New("file is nil")
 	errEmptyPath        = errors.New("file path can't be empty")
 	errChunkSizeInvalid = errors.New("chunk size must be greater than zero")
+	errSizeInvalid      = errors.New("size can't be negative")
+	errNilHash          = errors.New("hash is nil")
+	errHashUnsupported  = errors.New("the hash must be created by NewHash, NewHMAC or NewKeyedHash")
 )
 
 const (
 	defaultChunkSize = 4096
 )
 
-// Hash a hash calculate component
+// Hash a hash calculate component.
+// The extended APIs, like the chunk hashing, merkle tree, delta, manifest and checksum, are provided by the
+// package-level functions that take a Hash, so the interface is unchanged when adding them
 type Hash interface {
+	// Algorithm returns the name of the hash algorithm in lower case
+	Algorithm() string
 	// HashFromFile calculate the hash value of the file
 	// If you reuse the file reader, please set its offset to start position first, like os.File.Seek
 	HashFromFile(file io.Reader) (hashString string, err error)
+	// HashFromFileContext is like HashFromFile but stops reading and returns the ctx.Err() when the ctx is done
+	HashFromFileContext(ctx context.Context, file io.Reader) (hashString string, err error)
 	// HashFromFileName calculate the hash value of the file
 	HashFromFileName(path string) (hash string, err error)
+	// HashFromFileNameContext is like HashFromFileName but stops reading and returns the ctx.Err() when the ctx is done
+	HashFromFileNameContext(ctx context.Context, path string) (hash string, err error)
 	// HashFromFileChunk calculate the hash value of the file chunk
 	HashFromFileChunk(path string, offset int64, chunkSize int64) (hash string, err error)
+	// HashFromFileChunkContext is like HashFromFileChunk but returns the ctx.Err() when the ctx is done
+	HashFromFileChunkContext(ctx context.Context, path string, offset int64, chunkSize int64) (hash string, err error)
 	// Hash calculate the hash value of the bytes
 	Hash(bytes []byte) (hashString string)
 	// HashFromString calculate the hash value of the string
@@ -38,61 +52,138 @@ type Hash interface {
 	// the checkpoint hash is optional
 	// the entire file hash is required
 	CheckpointsHashFromFileName(path string, chunkSize int64, checkpointCount int) (hvs HashValues, err error)
-	// CheckpointsHashFromFile calculate the hash value of the entire file and first chunk and some checkpoints
+	// CheckpointsHashFromFileNameContext is like CheckpointsHashFromFileName but checks the ctx between chunks
+	// and returns the ctx.Err() when the ctx is done
+	CheckpointsHashFromFileNameContext(ctx context.Context, path string, chunkSize int64, checkpointCount int) (hvs HashValues, err error)
+	// CheckpointsHashFromFile calculate the hash value of the entire file and first chunk and some checkpoints,
+	// the file is read from its current offset, see CheckpointsHashFromFSFile for the file opened from any fs.FS
 	CheckpointsHashFromFile(f *os.File, chunkSize int64, checkpointCount int) (hvs HashValues, err error)
+	// CheckpointsHashFromFileContext is like CheckpointsHashFromFile but checks the ctx between chunks
+	// and returns the ctx.Err() when the ctx is done
+	CheckpointsHashFromFileContext(ctx context.Context, f *os.File, chunkSize int64, checkpointCount int) (hvs HashValues, err error)
 	// GetFileSizeAndHashCheckpoints get the file size and hash checkpoints from the specified file
 	GetFileSizeAndHashCheckpoints(path string, chunkSize int64, checkpointCount int) (size int64, hash string, hvs HashValues, err error)
-	// Compare whether the source file is equal to the destination file
+	// GetFileSizeAndHashCheckpointsContext is like GetFileSizeAndHashCheckpoints but returns the ctx.Err() when the ctx is done
+	GetFileSizeAndHashCheckpointsContext(ctx context.Context, path string, chunkSize int64, checkpointCount int) (size int64, hash string, hvs HashValues, err error)
+	// Compare whether the source file is equal to the destination file, see CompareFile for the detailed result.
+	// The destSize is only used to return false directly if it is less than or equal to zero, the actual size of the
+	// destination file is compared, so the files are not equal if the destination file is longer than the source file
+	// even though it starts with the data of the source file
 	Compare(chunkSize int64, checkpointCount int, sourceFile *os.File, sourceSize int64, dest string, destSize int64, offset *int64) (equal bool)
+	// CompareContext is like Compare but stops comparing and returns false when the ctx is done
+	CompareContext(ctx context.Context, chunkSize int64, checkpointCount int, sourceFile *os.File, sourceSize int64, dest string, destSize int64, offset *int64) (equal bool)
 	// QuickCompare if the forceChecksum is false, check whether the size and time are both equal, otherwise return false
 	QuickCompare(forceChecksum bool, sourceSize, destSize int64, sourceModTime, destModTime time.Time) (equal bool)
-	// CompareHashValues compare the HashValues from source file with the destination file
+	// CompareHashValues compare the HashValues from source file with the destination file, the files are not equal
+	// if the destination file is longer than the source file even though it starts with the data of the source file
 	CompareHashValues(dstPath string, sourceSize int64, sourceHash string, chunkSize int64, hvs HashValues) (equal bool, hv *HashValue)
+	// CompareHashValuesContext is like CompareHashValues but stops comparing and returns false when the ctx is done
+	CompareHashValuesContext(ctx context.Context, dstPath string, sourceSize int64, sourceHash string, chunkSize int64, hvs HashValues) (equal bool, hv *HashValue)
 	// CompareHashValuesWithFileName calculate the file hashes and return the last continuous hit HashValue.
 	// The offset in the HashValues must equal chunkSize * N, and N greater than zero
 	CompareHashValuesWithFileName(path string, chunkSize int64, hvs HashValues) (eq *HashValue, err error)
+	// CompareHashValuesWithFileNameContext is like CompareHashValuesWithFileName but checks the ctx between chunks
+	// and returns the ctx.Err() when the ctx is done
+	CompareHashValuesWithFileNameContext(ctx context.Context, path string, chunkSize int64, hvs HashValues) (eq *HashValue, err error)
 }
 
 type defaultHash struct {
-	factory hashFactory
+	algorithm string
+	factory   hashFactory
+	progress  ProgressObserver
+	encoding  Encoding
+	sparse    bool
+	mmap      bool
+	cache     *HashCache
+	fsys      fs.FS
+	// keyed whether the hash is returned by NewHMAC or NewKeyedHash
+	keyed bool
+}
+
+// toDefaultHash returns the implementation of the h, the package-level functions that rely on the hasher and
+// the options of the h only support the Hash that is created by this package
+func toDefaultHash(h Hash) (*defaultHash, error) {
+	if h == nil {
+		return nil, errNilHash
+	}
+	dh, ok := h.(*defaultHash)
+	if !ok || dh == nil {
+		return nil, errHashUnsupported
+	}
+	return dh, nil
 }
 
 func (dh *defaultHash) new() hash.Hash {
 	return dh.factory()
 }
 
+func (dh *defaultHash) Algorithm() string {
+	return dh.algorithm
+}
+
 func (dh *defaultHash) HashFromFile(file io.Reader) (hashString string, err error) {
+	return dh.HashFromFileContext(context.Background(), file)
+}
+
+func (dh *defaultHash) HashFromFileContext(ctx context.Context, file io.Reader) (hashString string, err error) {
+	sum, err := dh.sumFromFile(ctx, file)
+	if err != nil {
+		return hashString, err
+	}
+	return dh.encode(sum), nil
+}
+
+// sumFromFile calculate the raw hash value of the file
+func (dh *defaultHash) sumFromFile(ctx context.Context, file io.Reader) (sum []byte, err error) {
 	if file == nil {
-		return hashString, errNilFile
+		return nil, errNilFile
 	}
 	hash := dh.new()
-	reader := bufio.NewReader(file)
+	if data, unmap, ok := dh.mmapFromFile(file); ok {
+		defer unmap()
+		if _, err = dh.writeBytes(ctx, hash, data, 0, int64(len(data)), mmapWriteSize); err != nil {
+			return nil, err
+		}
+		return hash.Sum(nil), nil
+	}
+	file = dh.fileReader(file)
+	reader := bufio.NewReader(newContextReader(ctx, file))
 	_, err = reader.WriteTo(hash)
 	if err != nil {
-		return hashString, err
+		return nil, err
 	}
-	sum := hash.Sum(nil)
-	hashString = hex.EncodeToString(sum)
-	return hashString, nil
+	return hash.Sum(nil), nil
 }
 
 func (dh *defaultHash) HashFromFileName(path string) (hash string, err error) {
+	return dh.HashFromFileNameContext(context.Background(), path)
+}
+
+func (dh *defaultHash) HashFromFileNameContext(ctx context.Context, path string) (hash string, err error) {
 	f, err := dh.open(path)
 	if err != nil {
 		return hash, err
 	}
 	defer f.Close()
-	return dh.HashFromFile(f)
+	return dh.HashFromFileContext(ctx, f)
 }
 
 func (dh *defaultHash) HashFromFileChunk(path string, offset int64, chunkSize int64) (hash string, err error) {
+	return dh.HashFromFileChunkContext(context.Background(), path, offset, chunkSize)
+}
+
+func (dh *defaultHash) HashFromFileChunkContext(ctx context.Context, path string, offset int64, chunkSize int64) (hash string, err error) {
+	if err = ctx.Err(); err != nil {
+		return hash, err
+	}
 	f, err := dh.open(path)
 	if err != nil {
 		return hash, err
 	}
 	defer f.Close()
+	r, _ := fileReaderAt(f, 1)
 	chunk := make([]byte, chunkSize)
-	n, err := f.ReadAt(chunk, offset)
+	n, err := r.ReadAt(chunk, offset)
 	if err == io.EOF {
 		err = nil
 	}
@@ -105,9 +196,7 @@ func (dh *defaultHash) HashFromFileChunk(path string, offset int64, chunkSize in
 func (dh *defaultHash) Hash(bytes []byte) (hashString string) {
 	hash := dh.new()
 	hash.Write(bytes)
-	sum := hash.Sum(nil)
-	hashString = hex.EncodeToString(sum)
-	return hashString
+	return dh.encode(hash.Sum(nil))
 }
 
 func (dh *defaultHash) HashFromString(s string) (hash string) {
@@ -115,21 +204,54 @@ func (dh *defaultHash) HashFromString(s string) (hash string) {
 }
 
 func (dh *defaultHash) CheckpointsHashFromFileName(path string, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	return dh.CheckpointsHashFromFileNameContext(context.Background(), path, chunkSize, checkpointCount)
+}
+
+func (dh *defaultHash) CheckpointsHashFromFileNameContext(ctx context.Context, path string, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	// the raw hash values can't be persisted in the JSON, the files in the fs.FS have no inode to validate the cache,
+	// and the keyed hash values are not persisted to keep the MACs off the disk
+	if dh.cache != nil && dh.encoding != EncodingRaw && dh.fsys == nil && !dh.keyed {
+		return dh.cache.checkpointsHash(ctx, dh, path, chunkSize, checkpointCount)
+	}
+	return dh.checkpointsHashFromFileName(ctx, path, chunkSize, checkpointCount)
+}
+
+func (dh *defaultHash) checkpointsHashFromFileName(ctx context.Context, path string, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
 	f, err := dh.open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	return dh.CheckpointsHashFromFile(f, chunkSize, checkpointCount)
+	return dh.checkpointsHashFromFile(ctx, f, chunkSize, checkpointCount)
 }
 
 func (dh *defaultHash) CheckpointsHashFromFile(f *os.File, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	return dh.CheckpointsHashFromFileContext(context.Background(), f, chunkSize, checkpointCount)
+}
+
+func (dh *defaultHash) CheckpointsHashFromFileContext(ctx context.Context, f *os.File, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	return dh.checkpointsHashFromFile(ctx, f, chunkSize, checkpointCount)
+}
+
+// checkpointsHashFromFile calculate the hash value of the entire file and first chunk and some checkpoints,
+// the file can be an *os.File or a file opened from any fs.FS
+func (dh *defaultHash) checkpointsHashFromFile(ctx context.Context, f fs.File, chunkSize int64, checkpointCount int) (hvs HashValues, err error) {
+	if isNilFile(f) {
+		return nil, errNilFile
+	}
 	stat, err := f.Stat()
 	if err != nil {
 		return nil, err
 	}
 	fileSize := stat.Size()
+	hvs, chunkSize = buildHashValues(fileSize, chunkSize, checkpointCount)
+	err = dh.calcHashValuesWithFile(ctx, f, fileSize, chunkSize, hvs)
+	return hvs, err
+}
 
+// buildHashValues build the HashValues without hash of the first chunk, checkpoints and entire file,
+// returns the HashValues and the chunk size to calculate them
+func buildHashValues(fileSize int64, chunkSize int64, checkpointCount int) (hvs HashValues, actualChunkSize int64) {
 	// add first chunk hash
 	if chunkSize > 0 && fileSize > chunkSize {
 		hvs = append(hvs, NewHashValue(chunkSize, ""))
@@ -141,18 +263,16 @@ func (dh *defaultHash) CheckpointsHashFromFile(f *os.File, chunkSize int64, chec
 	}
 
 	// add checkpoint hash
-	hvs = append(hvs, dh.buildCheckpointHashValues(fileSize, chunkSize, checkpointCount)...)
+	hvs = append(hvs, buildCheckpointHashValues(fileSize, chunkSize, checkpointCount)...)
 
 	// add entire file hash
 	if (len(hvs) > 0 && hvs.Last().Offset < fileSize) || len(hvs) == 0 {
 		hvs = append(hvs, NewHashValue(fileSize, ""))
 	}
-
-	err = dh.calcHashValuesWithFile(f, chunkSize, hvs)
-	return hvs, err
+	return hvs, chunkSize
 }
 
-func (dh *defaultHash) buildCheckpointHashValues(fileSize int64, chunkSize int64, checkpointCount int) (hvs HashValues) {
+func buildCheckpointHashValues(fileSize int64, chunkSize int64, checkpointCount int) (hvs HashValues) {
 	if checkpointCount > 0 {
 		checkpointSize := fileSize / int64(checkpointCount)
 
@@ -176,35 +296,55 @@ func (dh *defaultHash) buildCheckpointHashValues(fileSize int64, chunkSize int64
 	return hvs
 }
 
-func (dh *defaultHash) calcHashValuesWithFile(f *os.File, chunkSize int64, hvs HashValues) error {
-	if chunkSize <= 0 {
-		return errChunkSizeInvalid
+func (dh *defaultHash) calcHashValuesWithFile(ctx context.Context, f io.Reader, fileSize int64, chunkSize int64, hvs HashValues) error {
+	_, err := dh.calcHashValuesWithFileOffset(ctx, f, dh.new(), 0, fileSize, chunkSize, hvs)
+	return err
+}
+
+// fileReader returns the reader to read the file from its current offset according to the options,
+// only the *os.File supports the sparse option
+func (dh *defaultHash) fileReader(r io.Reader) io.Reader {
+	if f, ok := r.(*os.File); ok && dh.sparse {
+		return newSparseReader(f)
 	}
-	if len(hvs) == 0 {
-		return nil
+	return r
+}
+
+// calcHashValues read the data from the r and write it to the h that has already hashed writeLen bytes,
+// then fill the hash of the uncompleted HashValues when reaching their offsets, returns the count of bytes hashed
+func (dh *defaultHash) calcHashValues(ctx context.Context, r io.Reader, h hash.Hash, writeLen int64, fileSize int64, chunkSize int64, hvs HashValues) (int64, error) {
+	if chunkSize <= 0 {
+		return writeLen, errChunkSizeInvalid
 	}
-	h := dh.new()
-	var writeLen int64
+	// skip the completed HashValues
 	hvi := 0
-	hv := hvs[0]
-	isEOF := false
+	for hvi < len(hvs) && len(hvs[hvi].Hash) > 0 {
+		hvi++
+	}
+	if hvi >= len(hvs) {
+		return writeLen, nil
+	}
+	hv := hvs[hvi]
 	chunk := make([]byte, chunkSize)
 	// calculate hash
 	for {
-		n, err := f.Read(chunk)
-		if err == io.EOF {
-			isEOF = true
-			err = nil
+		if err := ctx.Err(); err != nil {
+			return writeLen, err
 		}
+		// read the entire chunk even if the r returns less data, keep the offsets of the checkpoints
+		n, err := readChunk(r, chunk)
 		if err != nil {
-			return err
+			return writeLen, err
 		}
+		isEOF := n == 0
 
 		writeLen += int64(n)
 		h.Write(chunk[:n])
+		dh.progress.OnProgress(writeLen, fileSize)
 		if writeLen >= hv.Offset {
 			hv.Offset = writeLen
-			hv.Hash = hex.EncodeToString(h.Sum(nil))
+			hv.Hash = dh.encode(h.Sum(nil))
+			dh.progress.OnCheckpoint(hv)
 			hvi++
 			if hvi < len(hvs) {
 				hv = hvs[hvi]
@@ -215,18 +355,35 @@ func (dh *defaultHash) calcHashValuesWithFile(f *os.File, chunkSize int64, hvs H
 			break
 		}
 	}
-	return nil
+	return writeLen, nil
 }
 
-func (dh *defaultHash) open(path string) (*os.File, error) {
+// open the file from the fs.FS if the WithFS option is specified, otherwise from the local file system
+func (dh *defaultHash) open(path string) (fs.File, error) {
 	if len(path) == 0 {
 		return nil, errEmptyPath
 	}
+	if dh.fsys != nil {
+		return dh.fsys.Open(path)
+	}
 	return os.Open(path)
 }
 
+// stat returns the fs.FileInfo of the file from the fs.FS if the WithFS option is specified,
+// otherwise from the local file system, the symbolic links are followed
+func (dh *defaultHash) stat(path string) (fs.FileInfo, error) {
+	if dh.fsys != nil {
+		return fs.Stat(dh.fsys, path)
+	}
+	return os.Stat(path)
+}
+
 func (dh *defaultHash) GetFileSizeAndHashCheckpoints(path string, chunkSize int64, checkpointCount int) (size int64, hash string, hvs HashValues, err error) {
-	fileInfo, err := os.Stat(path)
+	return dh.GetFileSizeAndHashCheckpointsContext(context.Background(), path, chunkSize, checkpointCount)
+}
+
+func (dh *defaultHash) GetFileSizeAndHashCheckpointsContext(ctx context.Context, path string, chunkSize int64, checkpointCount int) (size int64, hash string, hvs HashValues, err error) {
+	fileInfo, err := dh.stat(path)
 	if err != nil {
 		return size, hash, hvs, err
 	}
@@ -235,7 +392,7 @@ func (dh *defaultHash) GetFileSizeAndHashCheckpoints(path string, chunkSize int6
 	}
 	size = fileInfo.Size()
 	if size > 0 {
-		hvs, err = dh.CheckpointsHashFromFileName(path, chunkSize, checkpointCount)
+		hvs, err = dh.CheckpointsHashFromFileNameContext(ctx, path, chunkSize, checkpointCount)
 		if err == nil && len(hvs) > 0 {
 			hash = hvs.Last().Hash
 		}
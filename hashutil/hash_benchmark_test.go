@@ -1,6 +1,15 @@
 package hashutil
 
-import "testing"
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	benchmarkLargeFileSize = 64 * 1024 * 1024
+)
 
 func BenchmarkHashFromFileName(b *testing.B) {
 	b.ReportAllocs()
@@ -35,3 +44,113 @@ func benchmarkCheckpointsHashFromFileName(b *testing.B, chunkSize int64, checkpo
 		}
 	}
 }
+
+func BenchmarkChunksHashFromFileName(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := ChunksHashFromFileName(testHash, testFilePath, 1024, 0)
+		if err != nil {
+			b.Errorf("benchmark test ChunksHashFromFileName error =>%v", err)
+		}
+	}
+}
+
+func BenchmarkHashFromFileName_LargeFile(b *testing.B) {
+	benchmarkHashFromLargeFile(b)
+}
+
+func BenchmarkHashFromFileName_LargeFileWithMmap(b *testing.B) {
+	benchmarkHashFromLargeFile(b, WithMmap())
+}
+
+func BenchmarkCheckpointsHashFromFileName_LargeFile(b *testing.B) {
+	benchmarkCheckpointsHashFromLargeFile(b)
+}
+
+func BenchmarkCheckpointsHashFromFileName_LargeFileWithMmap(b *testing.B) {
+	benchmarkCheckpointsHashFromLargeFile(b, WithMmap())
+}
+
+func benchmarkHashFromLargeFile(b *testing.B, opts ...Option) {
+	path := newBenchmarkLargeFile(b)
+	h, err := NewHash(DefaultHash, opts...)
+	if err != nil {
+		b.Fatalf("init hash component error => %v", err)
+	}
+	b.SetBytes(benchmarkLargeFileSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err = h.HashFromFileName(path)
+		if err != nil {
+			b.Errorf("benchmark test HashFromFileName error =>%v", err)
+		}
+	}
+}
+
+func benchmarkCheckpointsHashFromLargeFile(b *testing.B, opts ...Option) {
+	path := newBenchmarkLargeFile(b)
+	h, err := NewHash(DefaultHash, opts...)
+	if err != nil {
+		b.Fatalf("init hash component error => %v", err)
+	}
+	b.SetBytes(benchmarkLargeFileSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err = h.CheckpointsHashFromFileName(path, defaultChunkSize, 10)
+		if err != nil {
+			b.Errorf("benchmark test CheckpointsHashFromFileName error =>%v", err)
+		}
+	}
+}
+
+func BenchmarkMultiCheckpointsHashFromFileName_LargeFile(b *testing.B) {
+	path := newBenchmarkLargeFile(b)
+	algorithms := []string{MD5Hash, SHA1Hash, SHA256Hash, SHA512Hash}
+	b.SetBytes(benchmarkLargeFileSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := MultiCheckpointsHashFromFileName(path, defaultChunkSize, 10, algorithms)
+		if err != nil {
+			b.Errorf("benchmark test MultiCheckpointsHashFromFileName error =>%v", err)
+		}
+	}
+}
+
+// BenchmarkCheckpointsHashFromFileName_LargeFileWithAlgorithms is the baseline of the
+// BenchmarkMultiCheckpointsHashFromFileName_LargeFile that reads the file once per algorithm
+func BenchmarkCheckpointsHashFromFileName_LargeFileWithAlgorithms(b *testing.B) {
+	path := newBenchmarkLargeFile(b)
+	var hashes []Hash
+	for _, algorithm := range []string{MD5Hash, SHA1Hash, SHA256Hash, SHA512Hash} {
+		h, err := NewHash(algorithm)
+		if err != nil {
+			b.Fatalf("init hash component error => %v", err)
+		}
+		hashes = append(hashes, h)
+	}
+	b.SetBytes(benchmarkLargeFileSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, h := range hashes {
+			_, err := h.CheckpointsHashFromFileName(path, defaultChunkSize, 10)
+			if err != nil {
+				b.Errorf("benchmark test CheckpointsHashFromFileName error =>%v", err)
+			}
+		}
+	}
+}
+
+func newBenchmarkLargeFile(b *testing.B) string {
+	data := make([]byte, benchmarkLargeFileSize)
+	rand.New(rand.NewSource(1)).Read(data)
+	path := filepath.Join(b.TempDir(), "large.bin")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		b.Fatalf("write file error => %v", err)
+	}
+	return path
+}
@@ -1,6 +1,8 @@
 package hashutil
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -237,7 +239,7 @@ func TestCalcHashValuesWithFile(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			if err := testHash.calcHashValuesWithFile(tc.f, tc.chunkSize, tc.hvs); err != nil {
+			if err := testHash.calcHashValuesWithFile(context.Background(), tc.f, 0, tc.chunkSize, tc.hvs); err != nil {
 				t.Errorf("test calcHashValuesWithFile error => %v", err)
 			}
 		})
@@ -257,7 +259,7 @@ func TestCalcHashValuesWithFile_ReturnError(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			if err := testHash.calcHashValuesWithFile(tc.f, tc.chunkSize, tc.hvs); err == nil {
+			if err := testHash.calcHashValuesWithFile(context.Background(), tc.f, 0, tc.chunkSize, tc.hvs); err == nil {
 				t.Errorf("test calcHashValuesWithFile error, expect to get an error but get nil")
 			}
 		})
@@ -390,3 +392,99 @@ type readwrite struct {
 func (rw readwrite) WriteTo(w io.Writer) (n int64, err error) {
 	return 0, errors.New("write error test")
 }
+
+func TestHashContext_ReturnCanceledError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	f, err := os.Open(testFilePath)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f.Close()
+
+	testCases := []struct {
+		name string
+		fn   func() error
+	}{
+		{"HashFromFileContext", func() error {
+			_, err := testHash.HashFromFileContext(ctx, f)
+			return err
+		}},
+		{"HashFromFileNameContext", func() error {
+			_, err := testHash.HashFromFileNameContext(ctx, testFilePath)
+			return err
+		}},
+		{"HashFromFileChunkContext", func() error {
+			_, err := testHash.HashFromFileChunkContext(ctx, testFilePath, 0, 100)
+			return err
+		}},
+		{"CheckpointsHashFromFileNameContext", func() error {
+			_, err := testHash.CheckpointsHashFromFileNameContext(ctx, testFilePath, 20, 10)
+			return err
+		}},
+		{"CheckpointsHashFromFileContext", func() error {
+			_, err := testHash.CheckpointsHashFromFileContext(ctx, f, 20, 10)
+			return err
+		}},
+		{"GetFileSizeAndHashCheckpointsContext", func() error {
+			_, _, _, err := testHash.GetFileSizeAndHashCheckpointsContext(ctx, testFilePath, 20, 10)
+			return err
+		}},
+		{"CompareHashValuesWithFileNameContext", func() error {
+			_, err := testHash.CompareHashValuesWithFileNameContext(ctx, testFilePath, 1, append(HashValues{}, NewHashValue(2, "e529a9cea4a728eb9c5828b13b22844c")))
+			return err
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.fn(); !errors.Is(err, context.Canceled) {
+				t.Errorf("test %s error, expect to get error %v, but actual get %v", tc.name, context.Canceled, err)
+			}
+		})
+	}
+}
+
+// embeddedHash the alias of the Hash to embed it, the field name Hash conflicts with the method Hash
+type embeddedHash = Hash
+
+// customHash a Hash that is not created by this package
+type customHash struct {
+	embeddedHash
+}
+
+func TestToDefaultHash_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		h      Hash
+		expect error
+	}{
+		{"nil hash", nil, errNilHash},
+		{"custom hash", customHash{testHash}, errHashUnsupported},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ChunksHashFromFileName(tc.h, testFilePath, 100, 1); !errors.Is(err, tc.expect) {
+				t.Errorf("test ChunksHashFromFileName error, expect to get error %v, but actual get %v", tc.expect, err)
+				return
+			}
+			if result := CompareFile(tc.h, 100, 10, nil, 1, testFilePath); !errors.Is(result.Err, tc.expect) {
+				t.Errorf("test CompareFile error, expect to get error %v, but actual get %v", tc.expect, result.Err)
+			}
+		})
+	}
+}
+
+func TestGenerateSignature_CustomHash(t *testing.T) {
+	sig, err := GenerateSignature(customHash{testHash}, bytes.NewReader([]byte("hello gopher")), 4)
+	if err != nil {
+		t.Errorf("test GenerateSignature with custom hash error => %v", err)
+		return
+	}
+	if len(sig.Blocks) != 3 || sig.Blocks[0].Strong != testHash.HashFromString("hell") {
+		t.Errorf("test GenerateSignature with custom hash error, get unexpected signature => %+v", sig)
+	}
+}
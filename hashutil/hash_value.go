@@ -4,7 +4,8 @@ package hashutil
 type HashValue struct {
 	// Offset the file data to calculate the hash value from zero to offset
 	Offset int64 `json:"offset"`
-	// Hash the file checkpoint hash value
+	// Hash the file checkpoint hash value, encoded with the encoding of the Hash, the default is hex.
+	// It is the raw bytes with the EncodingRaw, then the HashValue can't be marshaled to the JSON
 	Hash string `json:"hash"`
 }
 
@@ -26,3 +27,17 @@ func (hvs HashValues) Last() *HashValue {
 	}
 	return nil
 }
+
+// clone returns a deep copy of the HashValues
+func (hvs HashValues) clone() HashValues {
+	if hvs == nil {
+		return nil
+	}
+	c := make(HashValues, len(hvs))
+	for i, hv := range hvs {
+		if hv != nil {
+			c[i] = NewHashValue(hv.Offset, hv.Hash)
+		}
+	}
+	return c
+}
@@ -21,3 +21,15 @@ func TestHashValues(t *testing.T) {
 		t.Errorf("test TestHashValues.Last error, expect:%s, actual:%s", expect, actual)
 	}
 }
+
+func TestHashValues_Clone(t *testing.T) {
+	var nilHvs HashValues
+	if nilHvs.clone() != nil {
+		t.Errorf("test HashValues.clone error, expect get nil")
+	}
+	hvs := HashValues{NewHashValue(1, "21cc28409729565fc1a4d2dd92db269f"), nil}
+	c := hvs.clone()
+	if len(c) != len(hvs) || c[0] == hvs[0] || *c[0] != *hvs[0] || c[1] != nil {
+		t.Errorf("test HashValues.clone error, expect to get a deep copy of the HashValues")
+	}
+}
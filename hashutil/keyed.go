@@ -0,0 +1,83 @@
+package hashutil
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+)
+
+const (
+	// HMACPrefix the prefix of the algorithm name of the Hash that is returned by NewHMAC, like hmac-sha256
+	HMACPrefix = "hmac-"
+	// KeyedPrefix the prefix of the algorithm name of the Hash that is returned by NewKeyedHash, like keyed-blake2b-256
+	KeyedPrefix = "keyed-"
+)
+
+var (
+	errEmptyKey = errors.New("key can't be empty")
+)
+
+// NewHMAC return the hash implementation that calculates all the hash values with the HMAC of the specified
+// hash algorithm and the key, the algorithm name of it is like hmac-sha256
+func NewHMAC(algorithm string, key []byte, opts ...Option) (Hash, error) {
+	if len(key) == 0 {
+		return nil, errEmptyKey
+	}
+	f, err := getFactory(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	// copy the key to avoid being modified by the caller
+	key = append([]byte{}, key...)
+	factory := func() hash.Hash {
+		return hmac.New(f, key)
+	}
+	return newKeyedHash(HMACPrefix+strings.ToLower(algorithm), factory, opts...)
+}
+
+// NewKeyedHash return the hash implementation that calculates all the hash values with the keyed mode of the
+// BLAKE2 hash algorithm, the algorithm name of it is like keyed-blake2b-256.
+// Supports BLAKE2b256Hash and BLAKE2b512Hash with the key up to 64 bytes, and BLAKE2s256Hash with the key up to 32 bytes
+func NewKeyedHash(algorithm string, key []byte, opts ...Option) (Hash, error) {
+	if len(key) == 0 {
+		return nil, errEmptyKey
+	}
+	algorithm = strings.ToLower(algorithm)
+	var newKeyed func(key []byte) (hash.Hash, error)
+	switch algorithm {
+	case BLAKE2b256Hash:
+		newKeyed = blake2b.New256
+	case BLAKE2b512Hash:
+		newKeyed = blake2b.New512
+	case BLAKE2s256Hash:
+		newKeyed = blake2s.New256
+	default:
+		return nil, fmt.Errorf("unsupported keyed hash algorithm => %s", algorithm)
+	}
+	// validate the key size
+	if _, err := newKeyed(key); err != nil {
+		return nil, err
+	}
+	key = append([]byte{}, key...)
+	factory := func() hash.Hash {
+		// the error is always nil after the key is validated
+		h, _ := newKeyed(key)
+		return h
+	}
+	return newKeyedHash(KeyedPrefix+algorithm, factory, opts...)
+}
+
+// newKeyedHash create the hash like newHash and mark it as keyed
+func newKeyedHash(algorithm string, f hashFactory, opts ...Option) (Hash, error) {
+	dh, err := newHash(algorithm, f, opts...)
+	if err != nil {
+		return nil, err
+	}
+	dh.keyed = true
+	return dh, nil
+}
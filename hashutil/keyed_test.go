@@ -0,0 +1,138 @@
+package hashutil
+
+import (
+	"testing"
+)
+
+func TestNewHMAC(t *testing.T) {
+	key := []byte("key")
+	input := "The quick brown fox jumps over the lazy dog"
+	testCases := []struct {
+		algorithm       string
+		expectAlgorithm string
+		expect          string
+	}{
+		{MD5Hash, "hmac-md5", "80070713463e7749b90c2dc24911e275"},
+		{"SHA256", "hmac-sha256", "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			h, err := NewHMAC(tc.algorithm, key)
+			if err != nil {
+				t.Errorf("test NewHMAC error => %v", err)
+				return
+			}
+			if h.Algorithm() != tc.expectAlgorithm {
+				t.Errorf("test NewHMAC error, expect the algorithm is %s, but actual get %s", tc.expectAlgorithm, h.Algorithm())
+			}
+			if actual := h.HashFromString(input); actual != tc.expect {
+				t.Errorf("test NewHMAC error, expect: %s, but actual: %s", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestNewKeyedHash(t *testing.T) {
+	key := []byte("key")
+	input := "The quick brown fox jumps over the lazy dog"
+	testCases := []struct {
+		algorithm string
+		expect    string
+	}{
+		{BLAKE2b256Hash, "27fbd5f2cdea2c98fa372a1a3b572a2f51c06bc627e306de84663f48c8b0eb13"},
+		{BLAKE2b512Hash, "66f642208454bf2e066dac9eab68fae0146bb544c1d46e1f427008f068a45d872cd0c1fc23e7ba82a95d084aadf5e4af9edaf761fb6ced9e485a28c59a3f714c"},
+		{BLAKE2s256Hash, "eec94d00b8c9d214636adfad587bc9c75f271d7a64d9639ef2e959f94da468e6"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			h, err := NewKeyedHash(tc.algorithm, key)
+			if err != nil {
+				t.Errorf("test NewKeyedHash error => %v", err)
+				return
+			}
+			if h.Algorithm() != KeyedPrefix+tc.algorithm {
+				t.Errorf("test NewKeyedHash error, expect the algorithm is %s, but actual get %s", KeyedPrefix+tc.algorithm, h.Algorithm())
+			}
+			if actual := h.HashFromString(input); actual != tc.expect {
+				t.Errorf("test NewKeyedHash error, expect: %s, but actual: %s", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestNewHMAC_CheckpointsAndCompare(t *testing.T) {
+	h, err := NewHMAC(SHA256Hash, []byte("key"))
+	if err != nil {
+		t.Errorf("test NewHMAC error => %v", err)
+		return
+	}
+	other, err := NewHMAC(SHA256Hash, []byte("other key"))
+	if err != nil {
+		t.Errorf("test NewHMAC error => %v", err)
+		return
+	}
+	hash, err := h.HashFromFileName(testFilePath)
+	if err != nil {
+		t.Errorf("test HashFromFileName error => %v", err)
+		return
+	}
+	size, _, hvs, err := h.GetFileSizeAndHashCheckpoints(testFilePath, 100, 5)
+	if err != nil {
+		t.Errorf("test GetFileSizeAndHashCheckpoints error => %v", err)
+		return
+	}
+	if hvs.Last().Hash != hash {
+		t.Errorf("test CheckpointsHashFromFileName error, expect the entire file hash is %s, but actual get %s", hash, hvs.Last().Hash)
+		return
+	}
+	if equal, _ := h.CompareHashValues(testFilePath, size, hash, 100, hvs); !equal {
+		t.Errorf("test CompareHashValues error, expect the file is equal with the same key")
+	}
+	if equal, _ := other.CompareHashValues(testFilePath, size, hash, 100, hvs); equal {
+		t.Errorf("test CompareHashValues error, expect the file is not equal with another key")
+	}
+}
+
+func TestNewHMAC_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		algorithm string
+		key       []byte
+	}{
+		{"empty key", SHA256Hash, nil},
+		{"unsupported algorithm", "notexist", []byte("key")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewHMAC(tc.algorithm, tc.key)
+			if err == nil {
+				t.Errorf("test NewHMAC error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestNewKeyedHash_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		algorithm string
+		key       []byte
+	}{
+		{"empty key", BLAKE2b256Hash, nil},
+		{"unsupported algorithm", SHA256Hash, []byte("key")},
+		{"blake2b key too long", BLAKE2b512Hash, make([]byte, 65)},
+		{"blake2s key too long", BLAKE2s256Hash, make([]byte, 33)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewKeyedHash(tc.algorithm, tc.key)
+			if err == nil {
+				t.Errorf("test NewKeyedHash error, expect to get an error but get nil")
+			}
+		})
+	}
+}
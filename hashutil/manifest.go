@@ -0,0 +1,296 @@
+package hashutil
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/no-src/nsgo/fsutil"
+)
+
+const (
+	// SymlinkSkip ignore the symbolic links
+	SymlinkSkip SymlinkPolicy = iota
+	// SymlinkRecord record the symbolic links with their target paths instead of hashing the content
+	SymlinkRecord
+	// SymlinkFollow follow the symbolic links and hash the content of their targets
+	SymlinkFollow
+)
+
+var (
+	errRootNotDir           = errors.New("the root of the manifest must be a directory")
+	errFSSymlinkUnsupported = errors.New("only the symbolic links to the files can be followed in the fs.FS")
+)
+
+// SymlinkPolicy the policy to handle the symbolic links when hashing the directory
+type SymlinkPolicy int
+
+// ManifestOptions the options to build the Manifest
+type ManifestOptions struct {
+	// ChunkSize the chunk size to calculate the HashValues of every file, zero means the default chunk size
+	ChunkSize int64
+	// CheckpointCount the count of checkpoints of every file
+	CheckpointCount int
+	// Include the glob patterns of the files to hash, empty means all files.
+	// The pattern matches the slash-separated relative path or the base name, like path.Match
+	Include []string
+	// Exclude the glob patterns of the files and directories to ignore, take precedence over the Include
+	Exclude []string
+	// Symlink the policy to handle the symbolic links
+	Symlink SymlinkPolicy
+}
+
+// Manifest the hash manifest of a directory tree
+type Manifest struct {
+	// Algorithm the hash algorithm
+	Algorithm string `json:"algorithm"`
+	// Encoding the encoding of all the hash values, the zero value is EncodingHex
+	Encoding Encoding `json:"encoding"`
+	// ChunkSize the chunk size to calculate the HashValues
+	ChunkSize int64 `json:"chunk_size"`
+	// CheckpointCount the count of checkpoints of every file
+	CheckpointCount int `json:"checkpoint_count"`
+	// Digest the aggregate digest of the directory, calculated from the path, size, symlink and hash of all the entries.
+	// The mtime is excluded, so the digest is stable after copying the directory
+	Digest string `json:"digest"`
+	// Entries the files sorted by the path
+	Entries []*ManifestEntry `json:"entries"`
+}
+
+// ManifestEntry the hash info of a file in the Manifest
+type ManifestEntry struct {
+	// Path the slash-separated path relative to the root directory
+	Path string `json:"path"`
+	// Size the size of the file
+	Size int64 `json:"size"`
+	// ModTime the last modify time of the file
+	ModTime time.Time `json:"mod_time"`
+	// Algorithm the hash algorithm
+	Algorithm string `json:"algorithm"`
+	// Symlink the target path of the symbolic link, only for the SymlinkRecord policy
+	Symlink string `json:"symlink,omitempty"`
+	// HashValues the checkpoint HashValues of the file, the last one is the hash value of the entire file
+	HashValues HashValues `json:"hash_values,omitempty"`
+}
+
+// Hash returns the hash value of the entire file, returns empty string for the symbolic link
+func (e *ManifestEntry) Hash() string {
+	if hv := e.HashValues.Last(); hv != nil {
+		return hv.Hash
+	}
+	return ""
+}
+
+// HashDir walk the directory tree and build the deterministic Manifest that contains the HashValues of every file
+// and the aggregate digest of the directory with the h
+func HashDir(h Hash, root string, opts ManifestOptions) (m *Manifest, err error) {
+	return HashDirContext(context.Background(), h, root, opts)
+}
+
+// HashDirContext is like HashDir but returns the ctx.Err() when the ctx is done
+func HashDirContext(ctx context.Context, h Hash, root string, opts ManifestOptions) (m *Manifest, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, err
+	}
+	if err = dh.checkTextEncoding(); err != nil {
+		return nil, err
+	}
+	if len(root) == 0 {
+		return nil, errEmptyPath
+	}
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+	stat, err := dh.stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return nil, errRootNotDir
+	}
+	m = &Manifest{
+		Algorithm:       dh.algorithm,
+		Encoding:        dh.encoding,
+		ChunkSize:       opts.ChunkSize,
+		CheckpointCount: opts.CheckpointCount,
+	}
+	b := &manifestBuilder{
+		dh:      dh,
+		ctx:     ctx,
+		opts:    opts,
+		m:       m,
+		visited: make(map[string]bool),
+	}
+	if dh.fsys == nil {
+		realRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			return nil, err
+		}
+		b.visited[realRoot] = true
+	}
+	if err = b.walk(root, ""); err != nil {
+		return nil, err
+	}
+	sort.Slice(m.Entries, func(i, j int) bool {
+		return m.Entries[i].Path < m.Entries[j].Path
+	})
+	m.Digest = dh.manifestDigest(m.Entries)
+	return m, nil
+}
+
+// manifestDigest calculate the aggregate digest of the entries that are sorted by the path
+func (dh *defaultHash) manifestDigest(entries []*ManifestEntry) string {
+	h := dh.new()
+	for _, e := range entries {
+		h.Write([]byte(e.Path))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.FormatInt(e.Size, 10)))
+		h.Write([]byte{0})
+		h.Write([]byte(e.Symlink))
+		h.Write([]byte{0})
+		h.Write([]byte(e.Hash()))
+		h.Write([]byte{'\n'})
+	}
+	return dh.encode(h.Sum(nil))
+}
+
+type manifestBuilder struct {
+	dh   *defaultHash
+	ctx  context.Context
+	opts ManifestOptions
+	m    *Manifest
+	// visited the real paths of the directories that are walked, avoid the symbolic link loop
+	visited map[string]bool
+}
+
+// walk the directory recursively and collect the entries of the files
+func (b *manifestBuilder) walk(dir string, relDir string) error {
+	dirEntries, err := b.readDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirEntries {
+		if err = b.ctx.Err(); err != nil {
+			return err
+		}
+		name := b.dh.joinPath(dir, d.Name())
+		rel := path.Join(relDir, d.Name())
+		if fsutil.MatchAny(b.opts.Exclude, rel) {
+			continue
+		}
+		isSymlink, err := b.isSymlink(name, d)
+		if err != nil {
+			return err
+		}
+		if isSymlink {
+			err = b.addSymlink(name, rel)
+		} else if d.IsDir() {
+			err = b.walk(name, rel)
+		} else if d.Type().IsRegular() {
+			err = b.addFile(name, rel)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *manifestBuilder) readDir(dir string) ([]fs.DirEntry, error) {
+	if b.dh.fsys != nil {
+		return fs.ReadDir(b.dh.fsys, dir)
+	}
+	return os.ReadDir(dir)
+}
+
+func (b *manifestBuilder) isSymlink(name string, d fs.DirEntry) (bool, error) {
+	if b.dh.fsys != nil {
+		return d.Type()&fs.ModeSymlink != 0, nil
+	}
+	return fsutil.IsSymlink(name)
+}
+
+// addSymlink handle the symbolic link according to the policy. The fs.FS can't read the target of the symbolic link or
+// resolve its real path to avoid the loop, so only the symbolic links to the files can be followed in the fs.FS
+func (b *manifestBuilder) addSymlink(name string, rel string) error {
+	switch b.opts.Symlink {
+	case SymlinkRecord:
+		if !b.included(rel) {
+			return nil
+		}
+		if b.dh.fsys != nil {
+			return errFSSymlinkUnsupported
+		}
+		target, err := fsutil.Readlink(name)
+		if err != nil {
+			return err
+		}
+		stat, err := os.Lstat(name)
+		if err != nil {
+			return err
+		}
+		b.m.Entries = append(b.m.Entries, &ManifestEntry{
+			Path:      rel,
+			Size:      int64(len(target)),
+			ModTime:   stat.ModTime(),
+			Algorithm: b.dh.algorithm,
+			Symlink:   filepath.ToSlash(target),
+		})
+	case SymlinkFollow:
+		stat, err := b.dh.stat(name)
+		if err != nil {
+			return err
+		}
+		if !stat.IsDir() {
+			return b.addFile(name, rel)
+		}
+		if b.dh.fsys != nil {
+			return errFSSymlinkUnsupported
+		}
+		realPath, err := filepath.EvalSymlinks(name)
+		if err != nil {
+			return err
+		}
+		if b.visited[realPath] {
+			return nil
+		}
+		b.visited[realPath] = true
+		return b.walk(name, rel)
+	}
+	return nil
+}
+
+func (b *manifestBuilder) addFile(name string, rel string) error {
+	if !b.included(rel) {
+		return nil
+	}
+	stat, err := b.dh.stat(name)
+	if err != nil {
+		return err
+	}
+	hvs, err := b.dh.CheckpointsHashFromFileNameContext(b.ctx, name, b.opts.ChunkSize, b.opts.CheckpointCount)
+	if err != nil {
+		return err
+	}
+	b.m.Entries = append(b.m.Entries, &ManifestEntry{
+		Path:       rel,
+		Size:       stat.Size(),
+		ModTime:    stat.ModTime(),
+		Algorithm:  b.dh.algorithm,
+		HashValues: hvs,
+	})
+	return nil
+}
+
+func (b *manifestBuilder) included(rel string) bool {
+	return len(b.opts.Include) == 0 || fsutil.MatchAny(b.opts.Include, rel)
+}
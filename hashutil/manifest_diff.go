@@ -0,0 +1,171 @@
+package hashutil
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const (
+	// ChangeAdded the file only exists in the new manifest
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved the file only exists in the old manifest
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified the content of the file is changed
+	ChangeModified ChangeType = "modified"
+	// ChangeRenamed the file is renamed or moved without changing the content
+	ChangeRenamed ChangeType = "renamed"
+	// ChangeUnchanged the content of the file is unchanged
+	ChangeUnchanged ChangeType = "unchanged"
+)
+
+var (
+	errNilManifest = errors.New("manifest is nil")
+)
+
+// ChangeType the type of the change between two manifests
+type ChangeType string
+
+// ManifestChange the change of a file between two manifests
+type ManifestChange struct {
+	// Type the type of the change
+	Type ChangeType `json:"type"`
+	// Path the path in the new manifest, or the path in the old manifest for the removed file
+	Path string `json:"path"`
+	// OldPath the path in the old manifest, only for the renamed file
+	OldPath string `json:"old_path,omitempty"`
+	// MatchedOffset the offset of the last matching checkpoint, only for the modified file.
+	// The data before it is unchanged, so the sync can resume from this offset
+	MatchedOffset int64 `json:"matched_offset,omitempty"`
+	// DivergentOffset the offset of the first divergent checkpoint, only for the modified file,
+	// zero means there is no comparable checkpoint
+	DivergentOffset int64 `json:"divergent_offset,omitempty"`
+	// Old the entry in the old manifest
+	Old *ManifestEntry `json:"old,omitempty"`
+	// New the entry in the new manifest
+	New *ManifestEntry `json:"new,omitempty"`
+}
+
+// ManifestDiff the changes between two manifests
+type ManifestDiff struct {
+	// Changes all the changes sorted by the path
+	Changes []*ManifestChange `json:"changes"`
+}
+
+// HasChanges whether there is any change except the unchanged files
+func (d *ManifestDiff) HasChanges() bool {
+	for _, c := range d.Changes {
+		if c.Type != ChangeUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the changes with the specified change types
+func (d *ManifestDiff) Filter(types ...ChangeType) (changes []*ManifestChange) {
+	for _, c := range d.Changes {
+		for _, t := range types {
+			if c.Type == t {
+				changes = append(changes, c)
+				break
+			}
+		}
+	}
+	return changes
+}
+
+// DiffManifest compare the older manifest with the newer manifest and classify every file, the renamed files are
+// detected by matching the content hash of the removed and added files, except the empty files.
+// The two manifests must be built by the same hash algorithm and encoding
+func DiffManifest(older *Manifest, newer *Manifest) (*ManifestDiff, error) {
+	if older == nil || newer == nil {
+		return nil, errNilManifest
+	}
+	if older.Algorithm != newer.Algorithm {
+		return nil, fmt.Errorf("the manifests are built by different hash algorithms => %s %s", older.Algorithm, newer.Algorithm)
+	}
+	if older.Encoding != newer.Encoding {
+		return nil, fmt.Errorf("the manifests are built with different hash encodings => %d %d", older.Encoding, newer.Encoding)
+	}
+
+	oldEntries := make(map[string]*ManifestEntry, len(older.Entries))
+	for _, e := range older.Entries {
+		oldEntries[e.Path] = e
+	}
+	newEntries := make(map[string]*ManifestEntry, len(newer.Entries))
+	for _, e := range newer.Entries {
+		newEntries[e.Path] = e
+	}
+
+	diff := &ManifestDiff{}
+	// the removed files grouped by the content, for the rename detection
+	removed := make(map[string][]*ManifestEntry)
+	for _, e := range older.Entries {
+		if _, ok := newEntries[e.Path]; !ok {
+			key := contentKey(e)
+			removed[key] = append(removed[key], e)
+		}
+	}
+
+	for _, e := range newer.Entries {
+		oe, ok := oldEntries[e.Path]
+		if ok {
+			if contentKey(oe) == contentKey(e) {
+				diff.Changes = append(diff.Changes, &ManifestChange{Type: ChangeUnchanged, Path: e.Path, Old: oe, New: e})
+			} else {
+				matched, divergent := divergentOffset(oe.HashValues, e.HashValues)
+				diff.Changes = append(diff.Changes, &ManifestChange{Type: ChangeModified, Path: e.Path, MatchedOffset: matched, DivergentOffset: divergent, Old: oe, New: e})
+			}
+			continue
+		}
+		key := contentKey(e)
+		if candidates := removed[key]; len(candidates) > 0 && e.Size > 0 {
+			oe = candidates[0]
+			removed[key] = candidates[1:]
+			diff.Changes = append(diff.Changes, &ManifestChange{Type: ChangeRenamed, Path: e.Path, OldPath: oe.Path, Old: oe, New: e})
+			continue
+		}
+		diff.Changes = append(diff.Changes, &ManifestChange{Type: ChangeAdded, Path: e.Path, New: e})
+	}
+
+	for _, candidates := range removed {
+		for _, oe := range candidates {
+			diff.Changes = append(diff.Changes, &ManifestChange{Type: ChangeRemoved, Path: oe.Path, Old: oe})
+		}
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Path < diff.Changes[j].Path
+	})
+	return diff, nil
+}
+
+// contentKey returns the key to identify the content of the entry
+func contentKey(e *ManifestEntry) string {
+	if len(e.Symlink) > 0 {
+		return "symlink:" + e.Symlink
+	}
+	return strconv.FormatInt(e.Size, 10) + ":" + e.Hash()
+}
+
+// divergentOffset compare the checkpoints at the same offsets in order, returns the offset of the last matching
+// checkpoint and the offset of the first divergent checkpoint. The checkpoint hash is the hash of the data from
+// zero to its offset, so all the checkpoints before a matching one are matched too
+func divergentOffset(older HashValues, newer HashValues) (matched int64, divergent int64) {
+	newHashes := make(map[int64]string, len(newer))
+	for _, hv := range newer {
+		newHashes[hv.Offset] = hv.Hash
+	}
+	for _, hv := range older {
+		hash, ok := newHashes[hv.Offset]
+		if !ok {
+			continue
+		}
+		if hash != hv.Hash {
+			return matched, hv.Offset
+		}
+		matched = hv.Offset
+	}
+	return matched, 0
+}
@@ -0,0 +1,173 @@
+package hashutil
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffManifest(t *testing.T) {
+	root := newTestManifestDir(t)
+	opts := ManifestOptions{ChunkSize: 4, CheckpointCount: 2}
+	old, err := HashDir(testHash, root, opts)
+	if err != nil {
+		t.Errorf("test HashDir error => %v", err)
+		return
+	}
+
+	// modify a.txt, rename b.go, remove sub/deep/d.go, add e.txt and keep sub/c.txt unchanged
+	if err = os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello GOPHER"), 0644); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	if err = os.Rename(filepath.Join(root, "b.go"), filepath.Join(root, "sub", "b.go")); err != nil {
+		t.Errorf("rename file error => %v", err)
+		return
+	}
+	if err = os.Remove(filepath.Join(root, "sub", "deep", "d.go")); err != nil {
+		t.Errorf("remove file error => %v", err)
+		return
+	}
+	if err = os.WriteFile(filepath.Join(root, "e.txt"), []byte("new file"), 0644); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	new, err := HashDir(testHash, root, opts)
+	if err != nil {
+		t.Errorf("test HashDir error => %v", err)
+		return
+	}
+
+	diff, err := DiffManifest(old, new)
+	if err != nil {
+		t.Errorf("test DiffManifest error => %v", err)
+		return
+	}
+	testCases := []struct {
+		changeType      ChangeType
+		path            string
+		oldPath         string
+		matchedOffset   int64
+		divergentOffset int64
+	}{
+		{ChangeModified, "a.txt", "", 4, 8},
+		{ChangeAdded, "e.txt", "", 0, 0},
+		{ChangeRenamed, "sub/b.go", "b.go", 0, 0},
+		{ChangeUnchanged, "sub/c.txt", "", 0, 0},
+		{ChangeRemoved, "sub/deep/d.go", "", 0, 0},
+	}
+	if len(diff.Changes) != len(testCases) {
+		t.Errorf("test DiffManifest error, expect to get %d changes, but actual get %d", len(testCases), len(diff.Changes))
+		return
+	}
+	for i, tc := range testCases {
+		c := diff.Changes[i]
+		if c.Type != tc.changeType || c.Path != tc.path || c.OldPath != tc.oldPath || c.MatchedOffset != tc.matchedOffset || c.DivergentOffset != tc.divergentOffset {
+			t.Errorf("test DiffManifest error, expect to get %+v, but actual get %s %s %s %d %d", tc, c.Type, c.Path, c.OldPath, c.MatchedOffset, c.DivergentOffset)
+		}
+	}
+	if !diff.HasChanges() {
+		t.Errorf("test DiffManifest error, expect to have changes")
+	}
+	if changes := diff.Filter(ChangeAdded, ChangeRemoved); len(changes) != 2 {
+		t.Errorf("test Filter error, expect to get 2 changes, but actual get %d", len(changes))
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		t.Errorf("marshal ManifestDiff error => %v", err)
+		return
+	}
+	var actual ManifestDiff
+	if err = json.Unmarshal(data, &actual); err != nil {
+		t.Errorf("unmarshal ManifestDiff error => %v", err)
+		return
+	}
+	if len(actual.Changes) != len(diff.Changes) || actual.Changes[2].Type != ChangeRenamed || actual.Changes[2].OldPath != "b.go" {
+		t.Errorf("test DiffManifest error, the ManifestDiff is changed after the json round trip")
+	}
+}
+
+func TestDiffManifest_NoChanges(t *testing.T) {
+	root := newTestManifestDir(t)
+	m, err := HashDir(testHash, root, ManifestOptions{})
+	if err != nil {
+		t.Errorf("test HashDir error => %v", err)
+		return
+	}
+	diff, err := DiffManifest(m, m)
+	if err != nil {
+		t.Errorf("test DiffManifest error => %v", err)
+		return
+	}
+	if diff.HasChanges() || len(diff.Filter(ChangeUnchanged)) != len(m.Entries) {
+		t.Errorf("test DiffManifest error, expect all the files are unchanged")
+	}
+}
+
+func TestDiffManifest_EmptyFileNotRenamed(t *testing.T) {
+	old := &Manifest{Algorithm: DefaultHash, Entries: []*ManifestEntry{
+		{Path: "a.txt", HashValues: HashValues{NewHashValue(0, "empty")}},
+	}}
+	new := &Manifest{Algorithm: DefaultHash, Entries: []*ManifestEntry{
+		{Path: "b.txt", HashValues: HashValues{NewHashValue(0, "empty")}},
+	}}
+	diff, err := DiffManifest(old, new)
+	if err != nil {
+		t.Errorf("test DiffManifest error => %v", err)
+		return
+	}
+	if len(diff.Filter(ChangeAdded)) != 1 || len(diff.Filter(ChangeRemoved)) != 1 {
+		t.Errorf("test DiffManifest error, expect the empty files are not detected as renamed")
+	}
+}
+
+func TestDiffManifest_DifferentEncodings(t *testing.T) {
+	root := newTestManifestDir(t)
+	old, err := HashDir(testHash, root, ManifestOptions{})
+	if err != nil {
+		t.Errorf("test HashDir error => %v", err)
+		return
+	}
+	h, err := NewHash(DefaultHash, WithEncoding(EncodingBase64))
+	if err != nil {
+		t.Errorf("init hash component error => %v", err)
+		return
+	}
+	new, err := HashDir(h, root, ManifestOptions{})
+	if err != nil {
+		t.Errorf("test HashDir error => %v", err)
+		return
+	}
+	if new.Encoding != EncodingBase64 {
+		t.Errorf("test HashDir error, expect to record the encoding %d, but actual get %d", EncodingBase64, new.Encoding)
+	}
+	// all the files would be reported as modified if the hash values in different encodings are compared
+	if _, err = DiffManifest(old, new); err == nil {
+		t.Errorf("test DiffManifest error, expect to get an error with different encodings but get nil")
+	}
+}
+
+func TestDiffManifest_ReturnError(t *testing.T) {
+	m := &Manifest{Algorithm: DefaultHash}
+	testCases := []struct {
+		name string
+		old  *Manifest
+		new  *Manifest
+	}{
+		{"nil old manifest", nil, m},
+		{"nil new manifest", m, nil},
+		{"different algorithms", m, &Manifest{Algorithm: SHA256Hash}},
+		{"different encodings", m, &Manifest{Algorithm: DefaultHash, Encoding: EncodingBase64}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DiffManifest(tc.old, tc.new)
+			if err == nil {
+				t.Errorf("test DiffManifest error, expect to get an error but get nil")
+			}
+		})
+	}
+}
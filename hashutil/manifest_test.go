@@ -0,0 +1,186 @@
+package hashutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/no-src/nsgo/fsutil"
+)
+
+func TestHashDir(t *testing.T) {
+	root := newTestManifestDir(t)
+	testCases := []struct {
+		name   string
+		opts   ManifestOptions
+		expect []string
+	}{
+		{"all files", ManifestOptions{}, []string{"a.txt", "b.go", "sub/c.txt", "sub/deep/d.go"}},
+		{"include by base name", ManifestOptions{Include: []string{"*.go"}}, []string{"b.go", "sub/deep/d.go"}},
+		{"include by relative path", ManifestOptions{Include: []string{"sub/*.txt"}}, []string{"sub/c.txt"}},
+		{"exclude directory", ManifestOptions{Exclude: []string{"deep"}}, []string{"a.txt", "b.go", "sub/c.txt"}},
+		{"include and exclude", ManifestOptions{Include: []string{"*.txt"}, Exclude: []string{"sub"}}, []string{"a.txt"}},
+		{"with checkpoints", ManifestOptions{ChunkSize: 4, CheckpointCount: 2}, []string{"a.txt", "b.go", "sub/c.txt", "sub/deep/d.go"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := HashDir(testHash, root, tc.opts)
+			if err != nil {
+				t.Errorf("test HashDir error => %v", err)
+				return
+			}
+			testManifestPaths(t, m, tc.expect)
+			for _, e := range m.Entries {
+				expect, err := testHash.HashFromFileName(filepath.Join(root, filepath.FromSlash(e.Path)))
+				if err != nil {
+					t.Errorf("test HashFromFileName error => %v", err)
+					return
+				}
+				if e.Hash() != expect || e.Algorithm != DefaultHash {
+					t.Errorf("test HashDir error, expect the hash of %s is %s, but actual get %s", e.Path, expect, e.Hash())
+				}
+			}
+		})
+	}
+}
+
+func TestHashDir_Digest(t *testing.T) {
+	root := newTestManifestDir(t)
+	m, err := HashDir(testHash, root, ManifestOptions{})
+	if err != nil {
+		t.Errorf("test HashDir error => %v", err)
+		return
+	}
+
+	// the digest is not affected by the mtime
+	mTime := time.Now().Add(-time.Hour)
+	if err = os.Chtimes(filepath.Join(root, "a.txt"), mTime, mTime); err != nil {
+		t.Errorf("change file time error => %v", err)
+		return
+	}
+	touched, err := HashDir(testHash, root, ManifestOptions{})
+	if err != nil {
+		t.Errorf("test HashDir error => %v", err)
+		return
+	}
+	if touched.Digest != m.Digest {
+		t.Errorf("test HashDir error, expect the digest is not changed after modifying the mtime")
+	}
+
+	if err = os.WriteFile(filepath.Join(root, "sub", "c.txt"), []byte("drift"), 0644); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	drifted, err := HashDir(testHash, root, ManifestOptions{})
+	if err != nil {
+		t.Errorf("test HashDir error => %v", err)
+		return
+	}
+	if drifted.Digest == m.Digest {
+		t.Errorf("test HashDir error, expect the digest is changed after modifying the file")
+	}
+}
+
+func TestHashDir_Symlink(t *testing.T) {
+	root := newTestManifestDir(t)
+	if err := fsutil.Symlink("a.txt", filepath.Join(root, "link.txt")); err != nil {
+		t.Skipf("create symlink error => %v", err)
+	}
+	if err := fsutil.Symlink("sub", filepath.Join(root, "link_dir")); err != nil {
+		t.Skipf("create symlink error => %v", err)
+	}
+	// the symbolic link loop
+	if err := fsutil.Symlink("..", filepath.Join(root, "sub", "parent")); err != nil {
+		t.Skipf("create symlink error => %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		policy SymlinkPolicy
+		expect []string
+	}{
+		{"skip", SymlinkSkip, []string{"a.txt", "b.go", "sub/c.txt", "sub/deep/d.go"}},
+		{"record", SymlinkRecord, []string{"a.txt", "b.go", "link.txt", "link_dir", "sub/c.txt", "sub/deep/d.go", "sub/parent"}},
+		{"follow", SymlinkFollow, []string{"a.txt", "b.go", "link.txt", "link_dir/c.txt", "link_dir/deep/d.go", "sub/c.txt", "sub/deep/d.go"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := HashDir(testHash, root, ManifestOptions{Symlink: tc.policy})
+			if err != nil {
+				t.Errorf("test HashDir error => %v", err)
+				return
+			}
+			testManifestPaths(t, m, tc.expect)
+			for _, e := range m.Entries {
+				if e.Path == "link.txt" && tc.policy == SymlinkRecord && (e.Symlink != "a.txt" || e.Hash() != "") {
+					t.Errorf("test HashDir error, expect to record the symlink target")
+				}
+			}
+		})
+	}
+}
+
+func TestHashDir_ReturnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	root := newTestManifestDir(t)
+
+	testCases := []struct {
+		name string
+		ctx  context.Context
+		root string
+		opts ManifestOptions
+	}{
+		{"empty path", context.Background(), "", ManifestOptions{}},
+		{"not exist path", context.Background(), notExistFilePath, ManifestOptions{}},
+		{"file path", context.Background(), testFilePath, ManifestOptions{}},
+		{"bad pattern", context.Background(), root, ManifestOptions{Include: []string{"["}}},
+		{"canceled context", ctx, root, ManifestOptions{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := HashDirContext(tc.ctx, testHash, tc.root, tc.opts); err == nil {
+				t.Errorf("test HashDir error, expect to get an error but get nil")
+			} else if tc.ctx != context.Background() && !errors.Is(err, context.Canceled) {
+				t.Errorf("test HashDir error, expect to get error %v, but actual get %v", context.Canceled, err)
+			}
+		})
+	}
+}
+
+func newTestManifestDir(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.txt":         "hello gopher",
+		"b.go":          "package main",
+		"sub/c.txt":     "",
+		"sub/deep/d.go": "package deep",
+	}
+	for name, content := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("create dir error => %v", err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("write file error => %v", err)
+		}
+	}
+	return root
+}
+
+func testManifestPaths(t *testing.T, m *Manifest, expect []string) {
+	var actual []string
+	for _, e := range m.Entries {
+		actual = append(actual, e.Path)
+	}
+	if !slices.Equal(actual, expect) {
+		t.Errorf("expect to get the entries %v, but actual get %v", expect, actual)
+	}
+}
@@ -0,0 +1,222 @@
+package hashutil
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	merkleLeafPrefix = []byte{0x00}
+	merkleNodePrefix = []byte{0x01}
+
+	errMerkleLeafIndexOutOfRange = errors.New("merkle leaf index out of range")
+	errMerkleTreeIncomplete      = errors.New("merkle tree only contains the leaves, please build it from the data")
+)
+
+// MerkleTree the merkle tree built over the fixed-size chunks of the data.
+// The leaf hash is H(0x00 || chunk) and the node hash is H(0x01 || left || right),
+// the last node of a level without sibling is promoted to the upper level directly
+type MerkleTree struct {
+	// ChunkSize the size of every chunk except the last one
+	ChunkSize int64 `json:"chunk_size"`
+	// Size the total size of the data
+	Size int64 `json:"size"`
+	// Root the root digest of the merkle tree
+	Root string `json:"root"`
+	// Leaves the hash values of all the chunks in order
+	Leaves []string `json:"leaves"`
+	// levels the raw node hashes from the leaves level to the root level
+	levels [][][]byte
+	// encode encode the raw node hash with the encoding of the hash
+	encode func(sum []byte) string
+}
+
+// MerkleProof the inclusion proof of a chunk in the merkle tree
+type MerkleProof struct {
+	// Index the index of the chunk
+	Index int `json:"index"`
+	// Count the count of the leaves of the merkle tree, it decides which nodes are promoted without sibling
+	Count int `json:"count"`
+	// Siblings the sibling hashes from the leaf level to the root level
+	Siblings []*MerkleProofNode `json:"siblings"`
+}
+
+// MerkleProofNode the sibling node in the MerkleProof
+type MerkleProofNode struct {
+	// Hash the hash value of the sibling node
+	Hash string `json:"hash"`
+	// Left whether the sibling node is on the left side
+	Left bool `json:"left"`
+}
+
+// Proof generate the inclusion proof of the chunk with the specified index
+func (t *MerkleTree) Proof(index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(t.Leaves) {
+		return nil, errMerkleLeafIndexOutOfRange
+	}
+	if len(t.levels) == 0 {
+		return nil, errMerkleTreeIncomplete
+	}
+	proof := &MerkleProof{
+		Index: index,
+		Count: len(t.Leaves),
+	}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			proof.Siblings = append(proof.Siblings, &MerkleProofNode{
+				Hash: t.encode(level[sibling]),
+				Left: sibling < index,
+			})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// Diff returns the indexes of the chunks that are different from the other merkle tree,
+// include the chunks that only exist in one of them
+func (t *MerkleTree) Diff(other *MerkleTree) (indexes []int) {
+	if other == nil {
+		other = &MerkleTree{}
+	}
+	if t.Root == other.Root && t.ChunkSize == other.ChunkSize && t.Size == other.Size {
+		return nil
+	}
+	count := max(len(t.Leaves), len(other.Leaves))
+	for i := 0; i < count; i++ {
+		if i >= len(t.Leaves) || i >= len(other.Leaves) || t.Leaves[i] != other.Leaves[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// MerkleTreeFromFileName build the merkle tree over the fixed-size chunks of the file,
+// the chunks are hashed in parallel like ChunksHashFromFileName
+func MerkleTreeFromFileName(h Hash, path string, chunkSize int64, workers int) (tree *MerkleTree, err error) {
+	return MerkleTreeFromFileNameContext(context.Background(), h, path, chunkSize, workers)
+}
+
+// MerkleTreeFromFileNameContext is like MerkleTreeFromFileName but returns the ctx.Err() when the ctx is done
+func MerkleTreeFromFileNameContext(ctx context.Context, h Hash, path string, chunkSize int64, workers int) (tree *MerkleTree, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, err
+	}
+	f, err := dh.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r, workers := fileReaderAt(f, workers)
+	return dh.merkleTreeFromReaderAt(ctx, r, stat.Size(), chunkSize, workers)
+}
+
+// MerkleTreeFromReaderAt build the merkle tree over the fixed-size chunks of the reader,
+// the size is the count of bytes to read from the reader
+func MerkleTreeFromReaderAt(h Hash, r io.ReaderAt, size int64, chunkSize int64, workers int) (tree *MerkleTree, err error) {
+	return MerkleTreeFromReaderAtContext(context.Background(), h, r, size, chunkSize, workers)
+}
+
+// MerkleTreeFromReaderAtContext is like MerkleTreeFromReaderAt but returns the ctx.Err() when the ctx is done
+func MerkleTreeFromReaderAtContext(ctx context.Context, h Hash, r io.ReaderAt, size int64, chunkSize int64, workers int) (tree *MerkleTree, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, err
+	}
+	return dh.merkleTreeFromReaderAt(ctx, r, size, chunkSize, workers)
+}
+
+func (dh *defaultHash) merkleTreeFromReaderAt(ctx context.Context, r io.ReaderAt, size int64, chunkSize int64, workers int) (tree *MerkleTree, err error) {
+	if err = dh.checkTextEncoding(); err != nil {
+		return nil, err
+	}
+	leaves, err := dh.hashChunks(ctx, r, size, chunkSize, workers, merkleLeafPrefix)
+	if err != nil {
+		return nil, err
+	}
+	tree = &MerkleTree{
+		ChunkSize: chunkSize,
+		Size:      size,
+		Leaves:    make([]string, len(leaves)),
+		encode:    dh.encode,
+	}
+	for i, leaf := range leaves {
+		tree.Leaves[i] = dh.encode(leaf)
+	}
+
+	if len(leaves) == 0 {
+		// the root of the empty tree is the hash of the empty data
+		leaves = [][]byte{dh.new().Sum(nil)}
+	}
+	level := leaves
+	tree.levels = append(tree.levels, level)
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				next = append(next, dh.merkleNode(level[i], level[i+1]))
+			} else {
+				next = append(next, level[i])
+			}
+		}
+		level = next
+		tree.levels = append(tree.levels, level)
+	}
+	tree.Root = dh.encode(level[0])
+	return tree, nil
+}
+
+// VerifyMerkleProof verify the chunk is included in the merkle tree with the specified root by the inclusion proof.
+// The position of every sibling is derived from the Index and the Count of the proof, the proof is rejected if any
+// Left flag of the siblings disagrees with it, or the count of the siblings does not match the height of the tree
+func VerifyMerkleProof(h Hash, root string, chunk []byte, proof *MerkleProof) bool {
+	dh, err := toDefaultHash(h)
+	if err != nil || proof == nil || proof.Index < 0 || proof.Index >= proof.Count {
+		return false
+	}
+	leaf := dh.new()
+	leaf.Write(merkleLeafPrefix)
+	leaf.Write(chunk)
+	current := leaf.Sum(nil)
+	siblings := proof.Siblings
+	for index, count := proof.Index, proof.Count; count > 1; index, count = index>>1, (count+1)/2 {
+		if index^1 >= count {
+			// the last node of the level without sibling is promoted to the upper level
+			continue
+		}
+		if len(siblings) == 0 || siblings[0] == nil {
+			return false
+		}
+		sibling := siblings[0]
+		siblings = siblings[1:]
+		left := index&1 == 1
+		if sibling.Left != left {
+			return false
+		}
+		siblingHash, err := DecodeHash(dh.algorithm, sibling.Hash, dh.encoding)
+		if err != nil {
+			return false
+		}
+		if left {
+			current = dh.merkleNode(siblingHash, current)
+		} else {
+			current = dh.merkleNode(current, siblingHash)
+		}
+	}
+	return len(siblings) == 0 && dh.matchHash(root, current)
+}
+
+func (dh *defaultHash) merkleNode(left, right []byte) []byte {
+	h := dh.new()
+	h.Write(merkleNodePrefix)
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
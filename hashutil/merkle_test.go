@@ -0,0 +1,184 @@
+package hashutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestMerkleTree_Proof(t *testing.T) {
+	data := bytes.Repeat([]byte("hello gopher "), 100)
+	var chunkSize int64 = 64
+	for _, size := range []int64{0, 1, chunkSize, chunkSize + 1, chunkSize * 4, chunkSize*5 + 3, int64(len(data))} {
+		tree, err := MerkleTreeFromReaderAt(testHash, bytes.NewReader(data), size, chunkSize, 3)
+		if err != nil {
+			t.Errorf("build merkle tree error, size=%d => %v", size, err)
+			return
+		}
+		if len(tree.Root) == 0 {
+			t.Errorf("build merkle tree error, size=%d, expect to get the root hash but get empty", size)
+		}
+		for i := range tree.Leaves {
+			offset := int64(i) * chunkSize
+			chunk := data[offset:min(offset+chunkSize, size)]
+			proof, err := tree.Proof(i)
+			if err != nil {
+				t.Errorf("generate merkle proof error, size=%d index=%d => %v", size, i, err)
+				return
+			}
+			if !VerifyMerkleProof(testHash, tree.Root, chunk, proof) {
+				t.Errorf("verify merkle proof error, size=%d index=%d, expect get true but actual get false", size, i)
+			}
+			if VerifyMerkleProof(testHash, tree.Root, append([]byte("x"), chunk...), proof) {
+				t.Errorf("verify merkle proof error, size=%d index=%d, expect get false with the damaged chunk but actual get true", size, i)
+			}
+		}
+	}
+}
+
+func TestMerkleTree_Proof_ReturnError(t *testing.T) {
+	tree, err := MerkleTreeFromFileName(testHash, testFilePath, 100, 2)
+	if err != nil {
+		t.Errorf("build merkle tree error => %v", err)
+		return
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Errorf("marshal merkle tree error => %v", err)
+		return
+	}
+	var leavesOnlyTree MerkleTree
+	if err = json.Unmarshal(data, &leavesOnlyTree); err != nil {
+		t.Errorf("unmarshal merkle tree error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name  string
+		tree  *MerkleTree
+		index int
+	}{
+		{"negative index", tree, -1},
+		{"index out of range", tree, len(tree.Leaves)},
+		{"only contains the leaves", &leavesOnlyTree, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.tree.Proof(tc.index); err == nil {
+				t.Errorf("test MerkleTree.Proof error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestMerkleTree_Diff(t *testing.T) {
+	data := bytes.Repeat([]byte("hello gopher "), 100)
+	damaged := slices.Clone(data)
+	damaged[70] = 'x'
+	damaged[1000] = 'x'
+	var chunkSize int64 = 64
+
+	tree, err := MerkleTreeFromReaderAt(testHash, bytes.NewReader(data), int64(len(data)), chunkSize, 0)
+	if err != nil {
+		t.Errorf("build merkle tree error => %v", err)
+		return
+	}
+	damagedTree, err := MerkleTreeFromReaderAt(testHash, bytes.NewReader(damaged), int64(len(damaged)), chunkSize, 0)
+	if err != nil {
+		t.Errorf("build merkle tree error => %v", err)
+		return
+	}
+	shortTree, err := MerkleTreeFromReaderAt(testHash, bytes.NewReader(data), chunkSize*18, chunkSize, 0)
+	if err != nil {
+		t.Errorf("build merkle tree error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name   string
+		other  *MerkleTree
+		expect []int
+	}{
+		{"the same data", tree, nil},
+		{"damaged chunks", damagedTree, []int{1, 15}},
+		{"missing chunks", shortTree, []int{18, 19, 20}},
+		{"nil tree", nil, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tree.Diff(tc.other); !slices.Equal(actual, tc.expect) {
+				t.Errorf("test MerkleTree.Diff error, expect get %v but actual get %v", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestVerifyMerkleProof_InvalidProof(t *testing.T) {
+	tree, err := MerkleTreeFromReaderAt(testHash, bytes.NewReader([]byte("hello gopher")), 12, 4, 1)
+	if err != nil {
+		t.Errorf("build merkle tree error => %v", err)
+		return
+	}
+
+	proof, err := tree.Proof(0)
+	if err != nil {
+		t.Errorf("generate merkle proof error => %v", err)
+		return
+	}
+	if !VerifyMerkleProof(testHash, tree.Root, []byte("hell"), proof) {
+		t.Errorf("test VerifyMerkleProof error, expect get true but actual get false")
+		return
+	}
+	first, second := *proof.Siblings[0], *proof.Siblings[1]
+	flipped := []*MerkleProofNode{{Hash: first.Hash, Left: !first.Left}, &second}
+
+	testCases := []struct {
+		name  string
+		root  string
+		proof *MerkleProof
+	}{
+		{"nil proof", tree.Root, nil},
+		{"nil sibling", tree.Root, &MerkleProof{Count: 3, Siblings: []*MerkleProofNode{nil, &second}}},
+		{"invalid sibling hash", tree.Root, &MerkleProof{Count: 3, Siblings: []*MerkleProofNode{{Hash: "xyz"}, &second}}},
+		{"invalid root", "xyz", proof},
+		{"wrong index", tree.Root, &MerkleProof{Index: 1, Count: 3, Siblings: proof.Siblings}},
+		{"negative index", tree.Root, &MerkleProof{Index: -1, Count: 3, Siblings: proof.Siblings}},
+		{"index out of range", tree.Root, &MerkleProof{Index: 3, Count: 3, Siblings: proof.Siblings}},
+		{"wrong count", tree.Root, &MerkleProof{Count: 2, Siblings: proof.Siblings}},
+		{"without count", tree.Root, &MerkleProof{Siblings: proof.Siblings}},
+		{"disagreed left flag", tree.Root, &MerkleProof{Count: 3, Siblings: flipped}},
+		{"missing sibling", tree.Root, &MerkleProof{Count: 3, Siblings: proof.Siblings[:1]}},
+		{"extra sibling", tree.Root, &MerkleProof{Count: 3, Siblings: append(slices.Clone(proof.Siblings), &second)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if VerifyMerkleProof(testHash, tc.root, []byte("hell"), tc.proof) {
+				t.Errorf("test VerifyMerkleProof error, expect get false but actual get true")
+			}
+		})
+	}
+}
+
+func TestMerkleTreeFromFileName_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		path      string
+		chunkSize int64
+	}{
+		{"with empty path", "", 100},
+		{"with not exist file path", notExistFilePath, 100},
+		{"with zero chunk size", testFilePath, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := MerkleTreeFromFileName(testHash, tc.path, tc.chunkSize, 2); err == nil {
+				t.Errorf("test MerkleTreeFromFileName error, expect to get an error but get nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package hashutil
+
+import (
+	"context"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"runtime/debug"
+)
+
+const (
+	// mmapWriteSize the max size of the mapped data to write to the hasher every time, the ctx is checked between them
+	mmapWriteSize = 1024 * 1024
+)
+
+var (
+	errMmapUnsupported = errors.New("the memory-mapped file is unsupported")
+	errMmapFault       = errors.New("failed to access the memory-mapped file, it may be truncated while hashing")
+)
+
+// mmapFromFile map the file from its current offset if the mmap option is enabled and the r is an *os.File,
+// returns false if the option is disabled or failed to map the file, then read the file instead
+func (dh *defaultHash) mmapFromFile(r io.Reader) (data []byte, unmap func() error, ok bool) {
+	f, isFile := r.(*os.File)
+	if !dh.mmap || !isFile {
+		return nil, nil, false
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, nil, false
+	}
+	data, unmap, err = mmapFile(f, offset)
+	return data, unmap, err == nil
+}
+
+// calcHashValuesWithFileOffset is like calcHashValues but reads the file from its current offset,
+// the file is memory-mapped if the mmap option is enabled
+func (dh *defaultHash) calcHashValuesWithFileOffset(ctx context.Context, f io.Reader, h hash.Hash, writeLen int64, fileSize int64, chunkSize int64, hvs HashValues) (int64, error) {
+	if chunkSize <= 0 {
+		return writeLen, errChunkSizeInvalid
+	}
+	if data, unmap, ok := dh.mmapFromFile(f); ok {
+		defer unmap()
+		return dh.calcHashValuesWithBytes(ctx, data, h, writeLen, fileSize, chunkSize, hvs)
+	}
+	return dh.calcHashValues(ctx, dh.fileReader(f), h, writeLen, fileSize, chunkSize, hvs)
+}
+
+// calcHashValuesWithBytes is like calcHashValues but writes the data to the h directly, the data is the rest of the
+// file after the writeLen bytes. The data is walked chunk by chunk like calcHashValues and written in multiples of
+// the chunkSize, so the HashValues are the same as it, and it stops at the same offset as calcHashValues when
+// interrupted, then the snapshot of the hashing can be resumed by either of them
+func (dh *defaultHash) calcHashValuesWithBytes(ctx context.Context, data []byte, h hash.Hash, writeLen int64, fileSize int64, chunkSize int64, hvs HashValues) (int64, error) {
+	step := max(mmapWriteSize/chunkSize, 1) * chunkSize
+	// skip the completed HashValues
+	hvi := 0
+	for hvi < len(hvs) && len(hvs[hvi].Hash) > 0 {
+		hvi++
+	}
+	if hvi >= len(hvs) {
+		return writeLen, nil
+	}
+	hv := hvs[hvi]
+	start := writeLen
+	var offset, written int64
+	for {
+		n := min(chunkSize, int64(len(data))-offset)
+		offset += n
+		isCheckpoint := start+offset >= hv.Offset
+		if isCheckpoint || n == 0 || offset-written >= step {
+			var err error
+			if writeLen, err = dh.writeBytes(ctx, h, data[written:offset], writeLen, fileSize, step); err != nil {
+				return writeLen, err
+			}
+			written = offset
+		}
+		if isCheckpoint {
+			hv.Offset = writeLen
+			hv.Hash = dh.encode(h.Sum(nil))
+			dh.progress.OnCheckpoint(hv)
+			hvi++
+			if hvi >= len(hvs) {
+				return writeLen, nil
+			}
+			hv = hvs[hvi]
+		}
+		// the file is shorter than the checkpoint, leave it uncompleted
+		if n == 0 {
+			return writeLen, nil
+		}
+	}
+}
+
+// writeBytes write the data to the h that has already hashed writeLen bytes piece by piece, every piece is up to
+// the step bytes, and checks the ctx between them, returns the count of bytes hashed.
+// Accessing the mapped data faults if the file is truncated while it is mapped, returns the errMmapFault instead of crashing
+func (dh *defaultHash) writeBytes(ctx context.Context, h hash.Hash, data []byte, writeLen int64, fileSize int64, step int64) (written int64, err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isFault := r.(interface{ Addr() uintptr }); !isFault {
+				panic(r)
+			}
+			written, err = writeLen, errMmapFault
+		}
+	}()
+	for len(data) > 0 {
+		if err = ctx.Err(); err != nil {
+			return writeLen, err
+		}
+		n := int(min(int64(len(data)), step))
+		h.Write(data[:n])
+		data = data[n:]
+		writeLen += int64(n)
+		dh.progress.OnProgress(writeLen, fileSize)
+	}
+	return writeLen, nil
+}
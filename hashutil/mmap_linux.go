@@ -0,0 +1,38 @@
+package hashutil
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmapFile map the file from the offset to the end into the memory read-only with the sequential access hint,
+// returns the mapped data and the function to unmap it.
+// The file must not be truncated until it is unmapped, otherwise accessing the mapped data causes SIGBUS
+func mmapFile(f *os.File, offset int64) (data []byte, unmap func() error, err error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := stat.Size()
+	if !stat.Mode().IsRegular() || offset < 0 || offset >= size {
+		return nil, nil, errMmapUnsupported
+	}
+	// the offset of the mapping must be a multiple of the page size
+	pageOffset := offset % int64(os.Getpagesize())
+	mapped, err := unix.Mmap(int(f.Fd()), offset-pageOffset, int(size-offset+pageOffset), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the hint is optional, ignore the error
+	_ = unix.Madvise(mapped, unix.MADV_SEQUENTIAL)
+	// keep the file offset the same as reading the file to the end
+	if _, err = f.Seek(size, io.SeekStart); err != nil {
+		unix.Munmap(mapped)
+		return nil, nil, err
+	}
+	return mapped[pageOffset:], func() error {
+		return unix.Munmap(mapped)
+	}, nil
+}
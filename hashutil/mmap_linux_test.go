@@ -0,0 +1,278 @@
+package hashutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithMmap(t *testing.T) {
+	data := newTestRandomData(3*mmapWriteSize + 100)
+	path := newTestDataFile(t, data)
+	mmapHash, err := NewHash(SHA256Hash, WithMmap())
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	h, err := NewHash(SHA256Hash)
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name            string
+		path            string
+		chunkSize       int64
+		checkpointCount int
+	}{
+		{"large file", path, 4096, 10},
+		{"large file without checkpoint", path, 0, 0},
+		{"large file with the checkpoint size equals the chunk size", path, 4096, 1000},
+		{"small file", testFilePath, 100, 5},
+		{"empty file", newTestDataFile(t, nil), 100, 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expect, err := h.CheckpointsHashFromFileName(tc.path, tc.chunkSize, tc.checkpointCount)
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+				return
+			}
+			actual, err := mmapHash.CheckpointsHashFromFileName(tc.path, tc.chunkSize, tc.checkpointCount)
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFileName with mmap error => %v", err)
+				return
+			}
+			testHashValuesEqual(t, expect, actual)
+
+			hash, err := mmapHash.HashFromFileName(tc.path)
+			if err != nil {
+				t.Errorf("test HashFromFileName with mmap error => %v", err)
+				return
+			}
+			if hash != expect.Last().Hash {
+				t.Errorf("test HashFromFileName with mmap error, expect to get %s, but actual get %s", expect.Last().Hash, hash)
+			}
+		})
+	}
+}
+
+func TestWithMmap_FromOffset(t *testing.T) {
+	data := newTestRandomData(2*mmapWriteSize + 100)
+	f, err := os.Open(newTestDataFile(t, data))
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f.Close()
+	h, err := NewHash(SHA256Hash, WithMmap())
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+
+	// the offset is not a multiple of the page size
+	var offset int64 = 5000
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		t.Errorf("seek file error => %v", err)
+		return
+	}
+	actual, err := h.HashFromFile(f)
+	if err != nil {
+		t.Errorf("test HashFromFile with mmap error => %v", err)
+		return
+	}
+	if expect := h.Hash(data[offset:]); actual != expect {
+		t.Errorf("test HashFromFile with mmap error, expect to get %s, but actual get %s", expect, actual)
+	}
+	if current, _ := f.Seek(0, io.SeekCurrent); current != int64(len(data)) {
+		t.Errorf("test HashFromFile with mmap error, expect the file offset is %d, but actual get %d", len(data), current)
+	}
+}
+
+func TestWithMmap_Resume(t *testing.T) {
+	path := newTestDataFile(t, newTestRandomData(3*mmapWriteSize+100))
+	// the chunk size does not divide the mmapWriteSize and the checkpoints are farther apart than the mmapWriteSize,
+	// the interrupted hashing must stop at the chunk boundary either way
+	var chunkSize int64 = 1000
+	checkpointCount := 2
+	expect, err := testHash.CheckpointsHashFromFileName(path, chunkSize, checkpointCount)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name            string
+		interruptByMmap bool
+		resumeByMmap    bool
+	}{
+		{"interrupt and resume with mmap", true, true},
+		{"interrupt with mmap and resume without mmap", true, false},
+		{"interrupt without mmap and resume with mmap", false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			progress := WithProgress(ProgressFuncs{
+				Progress: func(processed int64, total int64) {
+					if processed >= mmapWriteSize {
+						cancel()
+					}
+				},
+			})
+			h, err := newTestMmapHash(tc.interruptByMmap, progress)
+			if err != nil {
+				t.Errorf("init hash component error => %v", err)
+				return
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Errorf("open file error => %v", err)
+				return
+			}
+			defer f.Close()
+
+			_, snapshot, err := ResumableCheckpointsHashFromFileContext(ctx, h, f, chunkSize, checkpointCount)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("test ResumableCheckpointsHashFromFileContext error, expect to get error %v, but actual get %v", context.Canceled, err)
+				return
+			}
+			if snapshot == nil || snapshot.Offset < mmapWriteSize || snapshot.Offset >= snapshot.FileSize {
+				t.Errorf("test ResumableCheckpointsHashFromFileContext error, expect to get the snapshot of the interrupted hashing")
+				return
+			}
+			if snapshot.Offset%chunkSize != 0 {
+				t.Errorf("test ResumableCheckpointsHashFromFileContext error, expect the offset %d is aligned to the chunk size %d", snapshot.Offset, chunkSize)
+				return
+			}
+
+			h, err = newTestMmapHash(tc.resumeByMmap)
+			if err != nil {
+				t.Errorf("init hash component error => %v", err)
+				return
+			}
+			actual, _, err := ResumeCheckpointsHashFromFile(h, f, snapshot)
+			if err != nil {
+				t.Errorf("test ResumeCheckpointsHashFromFile error => %v", err)
+				return
+			}
+			testHashValuesEqual(t, expect, actual)
+		})
+	}
+}
+
+func TestWithMmap_Truncated(t *testing.T) {
+	testCases := []struct {
+		name string
+		hash func(h Hash, f *os.File) error
+	}{
+		{"HashFromFile", func(h Hash, f *os.File) error {
+			_, err := h.HashFromFile(f)
+			return err
+		}},
+		{"ResumableCheckpointsHashFromFile", func(h Hash, f *os.File) error {
+			_, snapshot, err := ResumableCheckpointsHashFromFile(h, f, 4096, 10)
+			if snapshot != nil {
+				t.Errorf("test ResumableCheckpointsHashFromFile error, expect to get no snapshot of the broken hash state")
+			}
+			return err
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := newTestDataFile(t, newTestRandomData(3*mmapWriteSize+100))
+			h, err := NewHash(DefaultHash, WithMmap(), WithProgress(ProgressFuncs{
+				Progress: func(processed int64, total int64) {
+					if err := os.Truncate(path, 0); err != nil {
+						t.Errorf("truncate file error => %v", err)
+					}
+				},
+			}))
+			if err != nil {
+				t.Errorf("init hash component error => %v", err)
+				return
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Errorf("open file error => %v", err)
+				return
+			}
+			defer f.Close()
+
+			err = tc.hash(h, f)
+			if !errors.Is(err, errMmapFault) {
+				t.Errorf("test %s error, expect to get error %v, but actual get %v", tc.name, errMmapFault, err)
+			}
+		})
+	}
+}
+
+func TestMmapFile_ReturnError(t *testing.T) {
+	f, err := os.Open(testFilePath)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f.Close()
+	dir, err := os.Open(testDirPath)
+	if err != nil {
+		t.Errorf("open dir error => %v", err)
+		return
+	}
+	defer dir.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name   string
+		f      *os.File
+		offset int64
+	}{
+		{"offset at the end", f, stat.Size()},
+		{"negative offset", f, -1},
+		{"directory", dir, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := mmapFile(tc.f, tc.offset)
+			if !errors.Is(err, errMmapUnsupported) {
+				t.Errorf("test mmapFile error, expect to get error %v, but actual get %v", errMmapUnsupported, err)
+			}
+		})
+	}
+}
+
+func newTestMmapHash(mmap bool, opts ...Option) (Hash, error) {
+	if mmap {
+		opts = append(opts, WithMmap())
+	}
+	return NewHash(DefaultHash, opts...)
+}
+
+func newTestRandomData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+	return data
+}
+
+func newTestDataFile(t *testing.T, data []byte) string {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write file error => %v", err)
+	}
+	return path
+}
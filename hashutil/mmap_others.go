@@ -0,0 +1,12 @@
+//go:build !linux
+
+package hashutil
+
+import (
+	"os"
+)
+
+// mmapFile returns the errMmapUnsupported, the memory-mapped file hashing is only supported on Linux
+func mmapFile(f *os.File, offset int64) (data []byte, unmap func() error, err error) {
+	return nil, nil, errMmapUnsupported
+}
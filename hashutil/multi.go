@@ -0,0 +1,202 @@
+package hashutil
+
+import (
+	"context"
+	"errors"
+	"hash"
+	"io"
+	"runtime"
+	"sync"
+)
+
+const (
+	// multiBatchSize the size of the data to read from the file every time, the hashers are synchronized only at the
+	// checkpoints and the end of every batch, so it must be large enough to amortize the synchronization
+	multiBatchSize = 1024 * 1024
+)
+
+var (
+	errNoAlgorithm = errors.New("at least one hash algorithm is required")
+)
+
+// MultiHashFromFileName calculate the hash values of the file with several hash algorithms by reading the file only once,
+// returns a map of the lower-case hash algorithm to the hash value. The opts are applied to the hashes of all
+// the algorithms, only the encoding and fs options are supported, see MultiCheckpointsHashFromFileName
+func MultiHashFromFileName(path string, algorithms []string, opts ...Option) (hashes map[string]string, err error) {
+	return MultiHashFromFileNameContext(context.Background(), path, algorithms, opts...)
+}
+
+// MultiHashFromFileNameContext is like MultiHashFromFileName but checks the ctx between batches
+// and returns the ctx.Err() when the ctx is done
+func MultiHashFromFileNameContext(ctx context.Context, path string, algorithms []string, opts ...Option) (hashes map[string]string, err error) {
+	hvsMap, err := MultiCheckpointsHashFromFileNameContext(ctx, path, 0, 0, algorithms, opts...)
+	if err != nil {
+		return nil, err
+	}
+	hashes = make(map[string]string, len(hvsMap))
+	for algorithm, hvs := range hvsMap {
+		hashes[algorithm] = hvs.Last().Hash
+	}
+	return hashes, nil
+}
+
+// MultiCheckpointsHashFromFileName calculate the hash value of the entire file and first chunk and some checkpoints
+// with several hash algorithms by reading the file only once, returns a map of the lower-case hash algorithm to
+// the HashValues. The data of the file is written to all the hashers in parallel batch by batch.
+// The opts are applied to the hashes of all the algorithms, the hash values are encoded with the WithEncoding and
+// the file is read from the WithFS, the other options are ignored
+func MultiCheckpointsHashFromFileName(path string, chunkSize int64, checkpointCount int, algorithms []string, opts ...Option) (hvsMap map[string]HashValues, err error) {
+	return MultiCheckpointsHashFromFileNameContext(context.Background(), path, chunkSize, checkpointCount, algorithms, opts...)
+}
+
+// MultiCheckpointsHashFromFileNameContext is like MultiCheckpointsHashFromFileName but checks the ctx between batches
+// and returns the ctx.Err() when the ctx is done
+func MultiCheckpointsHashFromFileNameContext(ctx context.Context, path string, chunkSize int64, checkpointCount int, algorithms []string, opts ...Option) (hvsMap map[string]HashValues, err error) {
+	if len(algorithms) == 0 {
+		return nil, errNoAlgorithm
+	}
+	var dhs []*defaultHash
+	seen := make(map[string]bool, len(algorithms))
+	for _, algorithm := range algorithms {
+		h, err := NewHash(algorithm, opts...)
+		if err != nil {
+			return nil, err
+		}
+		dh := h.(*defaultHash)
+		if seen[dh.algorithm] {
+			continue
+		}
+		seen[dh.algorithm] = true
+		dhs = append(dhs, dh)
+	}
+
+	f, err := dhs[0].open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	fileSize := stat.Size()
+	hvs, chunkSize := buildHashValues(fileSize, chunkSize, checkpointCount)
+	if chunkSize <= 0 {
+		return nil, errChunkSizeInvalid
+	}
+	hvsList := make([]HashValues, len(dhs))
+	for i := range dhs {
+		hvsList[i] = hvs.clone()
+	}
+
+	mh := newMultiHasher(dhs)
+	defer mh.close()
+	if err = mh.calcHashValues(ctx, f, chunkSize, hvsList); err != nil {
+		return nil, err
+	}
+	hvsMap = make(map[string]HashValues, len(dhs))
+	for i, dh := range dhs {
+		hvsMap[dh.algorithm] = hvsList[i]
+	}
+	return hvsMap, nil
+}
+
+// multiHasher write the data to the hashers of all the algorithms, in parallel if there are several hashers and CPUs
+type multiHasher struct {
+	dhs    []*defaultHash
+	hashes []hash.Hash
+	chunks []chan []byte
+	wg     sync.WaitGroup
+}
+
+func newMultiHasher(dhs []*defaultHash) *multiHasher {
+	mh := &multiHasher{
+		dhs: dhs,
+	}
+	for _, dh := range dhs {
+		mh.hashes = append(mh.hashes, dh.new())
+	}
+	if len(mh.hashes) > 1 && runtime.GOMAXPROCS(0) > 1 {
+		for _, h := range mh.hashes {
+			chunks := make(chan []byte)
+			mh.chunks = append(mh.chunks, chunks)
+			go func(h hash.Hash) {
+				for chunk := range chunks {
+					h.Write(chunk)
+					mh.wg.Done()
+				}
+			}(h)
+		}
+	}
+	return mh
+}
+
+// calcHashValues read the data from the r batch by batch, the batch is a multiple of the chunkSize and walked chunk
+// by chunk like the calcHashValues of every algorithm, so the HashValues are the same as it, but the data is written
+// to the hashers only when reaching a checkpoint or the end of the batch to reduce the synchronization
+func (mh *multiHasher) calcHashValues(ctx context.Context, r io.Reader, chunkSize int64, hvsList []HashValues) error {
+	hvs := hvsList[0]
+	var writeLen int64
+	hvi := 0
+	isEOF := false
+	batch := make([]byte, max(multiBatchSize/chunkSize, 1)*chunkSize)
+	for !isEOF && hvi < len(hvs) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// read the entire batch even if the r returns less data, keep the offsets of the checkpoints
+		n, err := readChunk(r, batch)
+		if err != nil {
+			return err
+		}
+		data := batch[:n]
+		offset, written := 0, 0
+		for hvi < len(hvs) {
+			size := min(int(chunkSize), n-offset)
+			offset += size
+			writeLen += int64(size)
+			if writeLen >= hvs[hvi].Offset {
+				mh.Write(data[written:offset])
+				written = offset
+				for i, dh := range mh.dhs {
+					hv := hvsList[i][hvi]
+					hv.Offset = writeLen
+					hv.Hash = dh.encode(mh.hashes[i].Sum(nil))
+				}
+				hvi++
+			}
+			// read to end or the batch is finished
+			isEOF = size == 0
+			if isEOF || offset == len(batch) {
+				break
+			}
+		}
+		mh.Write(data[written:])
+	}
+	return nil
+}
+
+// Write writes the p to all the hashers and waits for all of them to finish, so the p can be reused after returning
+func (mh *multiHasher) Write(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if len(mh.chunks) == 0 {
+		for _, h := range mh.hashes {
+			h.Write(p)
+		}
+		return len(p), nil
+	}
+	mh.wg.Add(len(mh.chunks))
+	for _, chunks := range mh.chunks {
+		chunks <- p
+	}
+	mh.wg.Wait()
+	return len(p), nil
+}
+
+func (mh *multiHasher) close() {
+	for _, chunks := range mh.chunks {
+		close(chunks)
+	}
+}
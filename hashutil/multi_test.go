@@ -0,0 +1,177 @@
+package hashutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMultiHashFromFileName(t *testing.T) {
+	algorithms := []string{MD5Hash, SHA256Hash, CRC32Hash, "SHA256"}
+	hashes, err := MultiHashFromFileName(testFilePath, algorithms)
+	if err != nil {
+		t.Errorf("test MultiHashFromFileName error => %v", err)
+		return
+	}
+	if len(hashes) != 3 {
+		t.Errorf("test MultiHashFromFileName error, expect to get 3 hash values, but actual get %d", len(hashes))
+	}
+	if _, ok := hashes[SHA256Hash]; !ok {
+		t.Errorf("test MultiHashFromFileName error, expect the algorithm names are in lower case, but actual get %v", hashes)
+	}
+
+	for algorithm, actual := range hashes {
+		h, err := NewHash(algorithm)
+		if err != nil {
+			t.Errorf("init hash with [%s] algorithm error => %v", algorithm, err)
+			return
+		}
+		expect, err := h.HashFromFileName(testFilePath)
+		if err != nil {
+			t.Errorf("test HashFromFileName with [%s] algorithm error => %v", algorithm, err)
+			return
+		}
+		if actual != expect {
+			t.Errorf("test MultiHashFromFileName with [%s] algorithm error, expect: %s, but actual: %s", algorithm, expect, actual)
+		}
+	}
+}
+
+func TestMultiCheckpointsHashFromFileName(t *testing.T) {
+	testCases := []struct {
+		name            string
+		chunkSize       int64
+		checkpointCount int
+		algorithms      []string
+	}{
+		{"single algorithm", 20, 10, []string{SHA1Hash}},
+		{"multiple algorithms", 20, 10, []string{MD5Hash, SHA1Hash, SHA512Hash, XXH3Hash}},
+		{"only entire file", 0, 0, []string{MD5Hash, SHA256Hash}},
+		{"checkpoints in the same batch", 3, 1000, []string{MD5Hash, SHA256Hash}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hvsMap, err := MultiCheckpointsHashFromFileName(testFilePath, tc.chunkSize, tc.checkpointCount, tc.algorithms)
+			if err != nil {
+				t.Errorf("test MultiCheckpointsHashFromFileName error => %v", err)
+				return
+			}
+			for _, algorithm := range tc.algorithms {
+				h, err := NewHash(algorithm)
+				if err != nil {
+					t.Errorf("init hash with [%s] algorithm error => %v", algorithm, err)
+					return
+				}
+				expect, err := h.CheckpointsHashFromFileName(testFilePath, tc.chunkSize, tc.checkpointCount)
+				if err != nil {
+					t.Errorf("test CheckpointsHashFromFileName with [%s] algorithm error => %v", algorithm, err)
+					return
+				}
+				actual := hvsMap[algorithm]
+				if len(actual) != len(expect) {
+					t.Errorf("test MultiCheckpointsHashFromFileName with [%s] algorithm error, expect to get %d HashValues, but actual get %d", algorithm, len(expect), len(actual))
+					return
+				}
+				for i := range expect {
+					if actual[i].Offset != expect[i].Offset || actual[i].Hash != expect[i].Hash {
+						t.Errorf("test MultiCheckpointsHashFromFileName with [%s] algorithm error, expect:[%d => %s] actual:[%d => %s]", algorithm, expect[i].Offset, expect[i].Hash, actual[i].Offset, actual[i].Hash)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMultiCheckpointsHashFromFileName_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name       string
+		path       string
+		chunkSize  int64
+		algorithms []string
+	}{
+		{"without algorithm", testFilePath, 20, nil},
+		{"with unsupported algorithm", testFilePath, 20, []string{MD5Hash, "unknown algorithm"}},
+		{"with empty path", "", 20, []string{MD5Hash}},
+		{"with not exist file path", notExistFilePath, 20, []string{MD5Hash}},
+		{"with dir path", testDirPath, 20, []string{MD5Hash, SHA1Hash}},
+		{"with invalid chunk size", testFilePath, -1, []string{MD5Hash}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := MultiCheckpointsHashFromFileName(tc.path, tc.chunkSize, 10, tc.algorithms); err == nil {
+				t.Errorf("test MultiCheckpointsHashFromFileName error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestMultiCheckpointsHashFromFileName_WithOptions(t *testing.T) {
+	data := bytes.Repeat([]byte("hello gopher "), multiBatchSize/4)
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	fsys := fstest.MapFS{testFSFileName: &fstest.MapFile{Data: data}}
+	algorithms := []string{MD5Hash, "SHA256", BLAKE2b256Hash}
+
+	testCases := []struct {
+		name      string
+		path      string
+		chunkSize int64
+		opts      []Option
+	}{
+		{"with encoding", path, 1000, []Option{WithEncoding(EncodingMultihash)}},
+		{"with fs", testFSFileName, 4096, []Option{WithFS(fsys), WithEncoding(EncodingBase64)}},
+		{"chunk size larger than the batch", path, multiBatchSize + 7, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hvsMap, err := MultiCheckpointsHashFromFileName(tc.path, tc.chunkSize, 5, algorithms, tc.opts...)
+			if err != nil {
+				t.Errorf("test MultiCheckpointsHashFromFileName error => %v", err)
+				return
+			}
+			if len(hvsMap) != len(algorithms) {
+				t.Errorf("test MultiCheckpointsHashFromFileName error, expect to get %d HashValues, but actual get %d", len(algorithms), len(hvsMap))
+				return
+			}
+			for _, algorithm := range algorithms {
+				h, err := NewHash(algorithm, tc.opts...)
+				if err != nil {
+					t.Errorf("init hash with [%s] algorithm error => %v", algorithm, err)
+					return
+				}
+				expect, err := h.CheckpointsHashFromFileName(tc.path, tc.chunkSize, 5)
+				if err != nil {
+					t.Errorf("test CheckpointsHashFromFileName with [%s] algorithm error => %v", algorithm, err)
+					return
+				}
+				testHashValuesEqual(t, expect, hvsMap[strings.ToLower(algorithm)])
+			}
+		})
+	}
+}
+
+func TestMultiCheckpointsHashFromFileName_WithUnsupportedEncoding(t *testing.T) {
+	_, err := MultiCheckpointsHashFromFileName(testFilePath, 20, 10, []string{SHA256Hash, CRC32Hash}, WithEncoding(EncodingMultihash))
+	if err == nil {
+		t.Errorf("test MultiCheckpointsHashFromFileName error, expect to get an error but get nil")
+	}
+}
+
+func TestMultiHashFromFileNameContext_ReturnCanceledError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MultiHashFromFileNameContext(ctx, testFilePath, []string{MD5Hash, SHA1Hash}); !errors.Is(err, context.Canceled) {
+		t.Errorf("test MultiHashFromFileNameContext error, expect to get error %v, but actual get %v", context.Canceled, err)
+	}
+}
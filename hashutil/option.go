@@ -0,0 +1,64 @@
+package hashutil
+
+import "io/fs"
+
+// Option the option of the Hash component
+type Option func(dh *defaultHash)
+
+// WithProgress register a progress observer to receive the progress of hashing the file chunk by chunk
+func WithProgress(observer ProgressObserver) Option {
+	return func(dh *defaultHash) {
+		if observer != nil {
+			dh.progress = observer
+		}
+	}
+}
+
+// WithSparse enable the hole-aware reading of the sparse files, the holes are detected with SEEK_DATA and SEEK_HOLE
+// and filled with zeros without reading them from the disk, the hash values are identical to reading the entire file.
+// It is only supported on Linux, and ignored on other platforms
+func WithSparse() Option {
+	return func(dh *defaultHash) {
+		dh.sparse = true
+	}
+}
+
+// WithMmap enable hashing the local files by mapping them into the memory with the sequential access hint,
+// it is faster than reading the large files chunk by chunk. It is only supported on Linux, and falls back to
+// reading the file if failed to map it or on other platforms
+func WithMmap() Option {
+	return func(dh *defaultHash) {
+		dh.mmap = true
+	}
+}
+
+// WithCache use the persistent HashCache to return the cached HashValues of the unchanged files in the
+// CheckpointsHashFromFileName and GetFileSizeAndHashCheckpoints instead of hashing them again.
+// The cache is ignored with the EncodingRaw and the keyed hash that is returned by NewHMAC or NewKeyedHash,
+// and failing to write the cache does not fail the hashing
+func WithCache(cache *HashCache) Option {
+	return func(dh *defaultHash) {
+		dh.cache = cache
+	}
+}
+
+// WithFS read the files from the fsys instead of the local file system in all the path-based methods, like embed.FS,
+// zip.Reader and fstest.MapFS, the paths must be slash-separated and unrooted as the fs.ValidPath requires.
+// The sparse, mmap and cache options are ignored for the files in the fsys
+func WithFS(fsys fs.FS) Option {
+	return func(dh *defaultHash) {
+		dh.fsys = fsys
+	}
+}
+
+// WithEncoding set the encoding of all the hash values that are returned by the Hash, the default is EncodingHex.
+// The EncodingMultihash only supports the algorithms with a multihash code, otherwise NewHash returns an error.
+// The EncodingRaw is unsupported by the APIs whose results are stored in the JSON, like HashDir, ChunksHashFromFileName,
+// ContentDefinedChunks, MerkleTreeFromFileName, GenerateSignature and the resumable hashing.
+// The expected hash values to compare with are decoded with the encoding first, if failed, their encodings are detected
+// from the other encodings except the EncodingRaw, so they can be in any of them
+func WithEncoding(encoding Encoding) Option {
+	return func(dh *defaultHash) {
+		dh.encoding = encoding
+	}
+}
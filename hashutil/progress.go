@@ -0,0 +1,39 @@
+package hashutil
+
+// ProgressObserver observe the progress of hashing the file chunk by chunk
+type ProgressObserver interface {
+	// OnProgress is called after every chunk is hashed, the processed is the count of bytes hashed so far
+	// and the total is the size of the file
+	OnProgress(processed int64, total int64)
+	// OnCheckpoint is called when a checkpoint HashValue is completed
+	OnCheckpoint(hv *HashValue)
+}
+
+// ProgressFuncs an adapter to allow the use of ordinary functions as a ProgressObserver, the nil function is ignored
+type ProgressFuncs struct {
+	// Progress the function to receive the count of bytes hashed so far and the size of the file
+	Progress func(processed int64, total int64)
+	// Checkpoint the function to receive the completed checkpoint HashValue
+	Checkpoint func(hv *HashValue)
+}
+
+// OnProgress calls the Progress function if it is not nil
+func (pf ProgressFuncs) OnProgress(processed int64, total int64) {
+	if pf.Progress != nil {
+		pf.Progress(processed, total)
+	}
+}
+
+// OnCheckpoint calls the Checkpoint function if it is not nil
+func (pf ProgressFuncs) OnCheckpoint(hv *HashValue) {
+	if pf.Checkpoint != nil {
+		pf.Checkpoint(hv)
+	}
+}
+
+// nopProgress the default ProgressObserver that does nothing
+type nopProgress struct{}
+
+func (nopProgress) OnProgress(processed int64, total int64) {}
+
+func (nopProgress) OnCheckpoint(hv *HashValue) {}
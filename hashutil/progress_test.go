@@ -0,0 +1,77 @@
+package hashutil
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWithProgress(t *testing.T) {
+	stat, err := os.Stat(testFilePath)
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+	fileSize := stat.Size()
+
+	var lastProcessed, lastTotal int64
+	var checkpoints HashValues
+	observer := ProgressFuncs{
+		Progress: func(processed int64, total int64) {
+			if processed < lastProcessed {
+				t.Errorf("test WithProgress error, the processed bytes go backwards from %d to %d", lastProcessed, processed)
+			}
+			lastProcessed, lastTotal = processed, total
+		},
+		Checkpoint: func(hv *HashValue) {
+			checkpoints = append(checkpoints, hv)
+		},
+	}
+	h, err := NewHash(DefaultHash, WithProgress(observer))
+	if err != nil {
+		t.Errorf("init hash component error => %v", err)
+		return
+	}
+
+	hvs, err := h.CheckpointsHashFromFileName(testFilePath, 20, 10)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+		return
+	}
+	if lastProcessed != fileSize || lastTotal != fileSize {
+		t.Errorf("test WithProgress error, expect processed and total to be %d, but actual get %d and %d", fileSize, lastProcessed, lastTotal)
+	}
+	if len(checkpoints) != len(hvs) {
+		t.Errorf("test WithProgress error, expect to get %d checkpoints, but actual get %d", len(hvs), len(checkpoints))
+		return
+	}
+
+	checkpoints, lastProcessed = nil, 0
+	eq, err := h.CompareHashValuesWithFileName(testFilePath, 20, hvs)
+	if err != nil {
+		t.Errorf("test CompareHashValuesWithFileName error => %v", err)
+		return
+	}
+	if eq == nil || eq.Offset != fileSize {
+		t.Errorf("test CompareHashValuesWithFileName error, expect to hit the entire file")
+	}
+	if len(checkpoints) != len(hvs) {
+		t.Errorf("test WithProgress error, expect to get %d matched checkpoints, but actual get %d", len(hvs), len(checkpoints))
+	}
+}
+
+func TestWithProgress_NilObserver(t *testing.T) {
+	h, err := NewHash(DefaultHash, WithProgress(nil))
+	if err != nil {
+		t.Errorf("init hash component error => %v", err)
+		return
+	}
+	if _, err = h.CheckpointsHashFromFileName(testFilePath, 20, 10); err != nil {
+		t.Errorf("test CheckpointsHashFromFileName with nil observer error => %v", err)
+	}
+}
+
+func TestProgressFuncs_NilFunctions(t *testing.T) {
+	var pf ProgressFuncs
+	pf.OnProgress(1, 2)
+	pf.OnCheckpoint(NewHashValue(1, ""))
+}
@@ -0,0 +1,41 @@
+package hashutil
+
+const (
+	// rollingMod the largest prime number smaller than 65536, the same as the Adler-32
+	rollingMod = 65521
+)
+
+// RollingChecksum the Adler-32 checksum over a fixed-size window that can roll forward byte by byte,
+// the Sum32 is always equal to the Adler-32 checksum of the current window
+type RollingChecksum struct {
+	a uint32
+	b uint32
+	n uint32
+}
+
+// NewRollingChecksum returns an instance of RollingChecksum with the initial window
+func NewRollingChecksum(window []byte) *RollingChecksum {
+	rc := &RollingChecksum{
+		a: 1,
+		n: uint32(len(window)),
+	}
+	for _, c := range window {
+		rc.a = (rc.a + uint32(c)) % rollingMod
+		rc.b = (rc.b + rc.a) % rollingMod
+	}
+	return rc
+}
+
+// Roll move the window forward by one byte, the out is the first byte of the current window
+// and the in is the next byte after the current window
+func (rc *RollingChecksum) Roll(out, in byte) {
+	rc.a = (rc.a + rollingMod - uint32(out) + uint32(in)) % rollingMod
+	// b' = b - n * out + a' - 1
+	b := uint64(rc.b) + rollingMod - uint64(rc.n)*uint64(out)%rollingMod + uint64(rc.a) + rollingMod - 1
+	rc.b = uint32(b % rollingMod)
+}
+
+// Sum32 returns the checksum of the current window
+func (rc *RollingChecksum) Sum32() uint32 {
+	return rc.b<<16 | rc.a
+}
@@ -0,0 +1,36 @@
+package hashutil
+
+import (
+	"bytes"
+	"hash/adler32"
+	"testing"
+)
+
+func TestRollingChecksum(t *testing.T) {
+	data := append(bytes.Repeat([]byte{0xff}, 5000), []byte("hello gopher, the rolling checksum is equal to the adler-32 checksum")...)
+	testCases := []struct {
+		name       string
+		windowSize int
+	}{
+		{"one byte window", 1},
+		{"small window", 16},
+		{"large window", 4096},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := NewRollingChecksum(data[:tc.windowSize])
+			for i := 0; ; i++ {
+				window := data[i : i+tc.windowSize]
+				if expect, actual := adler32.Checksum(window), rc.Sum32(); expect != actual {
+					t.Errorf("test RollingChecksum error at %d, expect get %d but actual get %d", i, expect, actual)
+					return
+				}
+				if i+tc.windowSize >= len(data) {
+					break
+				}
+				rc.Roll(data[i], data[i+tc.windowSize])
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package hashutil
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// sparseReader an io.Reader that detects the holes of the sparse file with SEEK_DATA and SEEK_HOLE,
+// and fills the holes with zeros instead of reading them from the disk
+type sparseReader struct {
+	f *os.File
+	// offset the logical offset of the next read
+	offset int64
+	// size the size of the file when the reader is created, the data after it is read directly
+	size int64
+	// dataStart the start offset of the current data region, the range from offset to dataStart is a hole
+	dataStart int64
+	// holeStart the end offset of the current data region
+	holeStart int64
+	// disabled the file system does not support SEEK_DATA and SEEK_HOLE, read the file directly
+	disabled bool
+}
+
+// newSparseReader returns a hole-aware io.Reader that reads the file from its current offset,
+// returns the file directly if failed to get the offset or size of the file
+func newSparseReader(f *os.File) io.Reader {
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return f
+	}
+	stat, err := f.Stat()
+	if err != nil || !stat.Mode().IsRegular() {
+		return f
+	}
+	return &sparseReader{
+		f:         f,
+		offset:    offset,
+		size:      stat.Size(),
+		dataStart: offset,
+		holeStart: offset,
+	}
+}
+
+func (r *sparseReader) Read(p []byte) (n int, err error) {
+	if r.disabled || r.offset >= r.size {
+		n, err = r.f.Read(p)
+		r.offset += int64(n)
+		return n, err
+	}
+	if r.offset >= r.holeStart {
+		if err = r.nextDataRegion(); err != nil {
+			return 0, err
+		}
+		if r.disabled {
+			return r.Read(p)
+		}
+	}
+	if r.offset < r.dataStart {
+		// in the hole, fill the zeros without reading
+		n = int(min(int64(len(p)), r.dataStart-r.offset))
+		clear(p[:n])
+		r.offset += int64(n)
+		return n, nil
+	}
+	n, err = r.f.Read(p[:min(int64(len(p)), r.holeStart-r.offset)])
+	r.offset += int64(n)
+	return n, err
+}
+
+// nextDataRegion find the next data region from the current offset, and move the file offset to the start of it
+func (r *sparseReader) nextDataRegion() error {
+	dataStart, err := r.f.Seek(r.offset, unix.SEEK_DATA)
+	if errors.Is(err, unix.ENXIO) {
+		// no more data, the rest of the file is a hole
+		dataStart, err = r.size, nil
+	}
+	if errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP) {
+		r.disabled = true
+		_, err = r.f.Seek(r.offset, io.SeekStart)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	holeStart := r.size
+	if dataStart < r.size {
+		if holeStart, err = r.f.Seek(dataStart, unix.SEEK_HOLE); err != nil {
+			return err
+		}
+	}
+	r.dataStart, r.holeStart = min(dataStart, r.size), min(holeStart, r.size)
+	_, err = r.f.Seek(r.dataStart, io.SeekStart)
+	return err
+}
@@ -0,0 +1,119 @@
+package hashutil
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewSparseReader(t *testing.T) {
+	testCases := []struct {
+		name    string
+		regions map[int64]string
+		size    int64
+		offset  int64
+	}{
+		{"empty file", nil, 0, 0},
+		{"entire hole", nil, 1024 * 1024, 0},
+		{"data in the middle", map[int64]string{512 * 1024: "hello gopher"}, 1024 * 1024, 0},
+		{"data at the start and end", map[int64]string{0: "hello", 1024*1024 - 6: "gopher"}, 1024 * 1024, 0},
+		{"read from offset", map[int64]string{0: "hello", 512 * 1024: "gopher"}, 1024 * 1024, 3},
+		{"without hole", map[int64]string{0: "hello gopher"}, 12, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTestSparseFile(t, tc.regions, tc.size)
+			expect := make([]byte, tc.size)
+			for offset, data := range tc.regions {
+				copy(expect[offset:], data)
+			}
+			if _, err := f.Seek(tc.offset, io.SeekStart); err != nil {
+				t.Errorf("seek file error => %v", err)
+				return
+			}
+			actual, err := io.ReadAll(newSparseReader(f))
+			if err != nil {
+				t.Errorf("test sparseReader error => %v", err)
+				return
+			}
+			if !bytes.Equal(actual, expect[tc.offset:]) {
+				t.Errorf("test sparseReader error, the data is different from the file")
+			}
+		})
+	}
+}
+
+func TestNewSparseReader_AppendData(t *testing.T) {
+	f := newTestSparseFile(t, map[int64]string{0: "hello"}, 4096)
+	r := newSparseReader(f)
+	// the data appended after the reader is created is read directly
+	if _, err := f.WriteAt([]byte("gopher"), 4096); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+	actual, err := io.ReadAll(r)
+	if err != nil {
+		t.Errorf("test sparseReader error => %v", err)
+		return
+	}
+	if len(actual) != 4096+len("gopher") || string(actual[4096:]) != "gopher" {
+		t.Errorf("test sparseReader error, expect to read the appended data")
+	}
+}
+
+func TestWithSparse(t *testing.T) {
+	f := newTestSparseFile(t, map[int64]string{100: "hello", 3 * 1024 * 1024: "gopher"}, 4*1024*1024)
+	sparseHash, err := NewHash(SHA256Hash, WithSparse())
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+	h, err := NewHash(SHA256Hash)
+	if err != nil {
+		t.Errorf("NewHash error => %v", err)
+		return
+	}
+
+	expect, err := h.CheckpointsHashFromFileName(f.Name(), 4096, 10)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+		return
+	}
+	actual, err := sparseHash.CheckpointsHashFromFileName(f.Name(), 4096, 10)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromFileName with sparse error => %v", err)
+		return
+	}
+	testHashValuesEqual(t, expect, actual)
+
+	hash, err := sparseHash.HashFromFileName(f.Name())
+	if err != nil {
+		t.Errorf("test HashFromFileName with sparse error => %v", err)
+		return
+	}
+	if hash != expect.Last().Hash {
+		t.Errorf("test HashFromFileName with sparse error, expect to get %s, but actual get %s", expect.Last().Hash, hash)
+	}
+}
+
+func newTestSparseFile(t *testing.T, regions map[int64]string, size int64) *os.File {
+	f, err := os.Create(filepath.Join(t.TempDir(), "sparse.img"))
+	if err != nil {
+		t.Fatalf("create file error => %v", err)
+	}
+	t.Cleanup(func() {
+		f.Close()
+	})
+	if err = f.Truncate(size); err != nil {
+		t.Fatalf("truncate file error => %v", err)
+	}
+	for offset, data := range regions {
+		if _, err = f.WriteAt([]byte(data), offset); err != nil {
+			t.Fatalf("write file error => %v", err)
+		}
+	}
+	return f
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package hashutil
+
+import (
+	"io"
+	"os"
+)
+
+// newSparseReader returns the file directly, the hole detection is only supported on Linux
+func newSparseReader(f *os.File) io.Reader {
+	return f
+}
@@ -0,0 +1,131 @@
+package hashutil
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+)
+
+var (
+	errNilHashState         = errors.New("hash state is nil")
+	errHashStateUnsupported = errors.New("the hasher does not support to save and restore its state")
+	errHashStateFileChanged = errors.New("the file size is changed since the hash state is saved")
+)
+
+// HashState the snapshot of the in-progress checkpoint hashing
+type HashState struct {
+	// Algorithm the hash algorithm
+	Algorithm string `json:"algorithm"`
+	// ChunkSize the chunk size to read the file
+	ChunkSize int64 `json:"chunk_size"`
+	// FileSize the size of the file when the hashing is started
+	FileSize int64 `json:"file_size"`
+	// Offset the count of bytes hashed
+	Offset int64 `json:"offset"`
+	// State the marshaled state of the hasher
+	State []byte `json:"state"`
+	// HashValues all the HashValues to calculate, the completed ones have the hash value
+	HashValues HashValues `json:"hash_values"`
+}
+
+// ResumableCheckpointsHashFromFile is like CheckpointsHashFromFSFile, but also returns the snapshot of the hashing.
+// If the hashing is interrupted by an error, the snapshot can be persisted and passed to the
+// ResumeCheckpointsHashFromFile to continue the hashing later. The hasher must implement the encoding.BinaryMarshaler
+// and encoding.BinaryUnmarshaler
+func ResumableCheckpointsHashFromFile(h Hash, f fs.File, chunkSize int64, checkpointCount int) (hvs HashValues, snapshot *HashState, err error) {
+	return ResumableCheckpointsHashFromFileContext(context.Background(), h, f, chunkSize, checkpointCount)
+}
+
+// ResumableCheckpointsHashFromFileContext is like ResumableCheckpointsHashFromFile but checks the ctx between chunks,
+// and returns the ctx.Err() with the snapshot when the ctx is done
+func ResumableCheckpointsHashFromFileContext(ctx context.Context, h Hash, f fs.File, chunkSize int64, checkpointCount int) (hvs HashValues, snapshot *HashState, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isNilFile(f) {
+		return nil, nil, errNilFile
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	hasher := dh.new()
+	if _, ok := hasher.(encoding.BinaryMarshaler); !ok {
+		return nil, nil, errHashStateUnsupported
+	}
+	fileSize := stat.Size()
+	hvs, chunkSize = buildHashValues(fileSize, chunkSize, checkpointCount)
+	return dh.resumeCheckpointsHash(ctx, f, hasher, 0, fileSize, chunkSize, hvs)
+}
+
+// ResumeCheckpointsHashFromFile continue the checkpoint hashing of the file from the snapshot, the file must be unchanged.
+// The file is read from the offset of the snapshot if it implements the io.Seeker, otherwise it must be read from the start
+func ResumeCheckpointsHashFromFile(h Hash, f fs.File, state *HashState) (hvs HashValues, snapshot *HashState, err error) {
+	return ResumeCheckpointsHashFromFileContext(context.Background(), h, f, state)
+}
+
+// ResumeCheckpointsHashFromFileContext is like ResumeCheckpointsHashFromFile but checks the ctx between chunks,
+// and returns the ctx.Err() with the new snapshot when the ctx is done
+func ResumeCheckpointsHashFromFileContext(ctx context.Context, h Hash, f fs.File, state *HashState) (hvs HashValues, snapshot *HashState, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isNilFile(f) {
+		return nil, nil, errNilFile
+	}
+	if state == nil {
+		return nil, nil, errNilHashState
+	}
+	if state.Algorithm != dh.algorithm {
+		return nil, nil, fmt.Errorf("the hash state is saved by another hash algorithm => %s", state.Algorithm)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if stat.Size() != state.FileSize {
+		return nil, nil, errHashStateFileChanged
+	}
+	hasher := dh.new()
+	unmarshaler, ok := hasher.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return nil, nil, errHashStateUnsupported
+	}
+	if err = unmarshaler.UnmarshalBinary(state.State); err != nil {
+		return nil, nil, err
+	}
+	if err = seekFile(f, state.Offset, state.Offset); err != nil {
+		return nil, nil, err
+	}
+	return dh.resumeCheckpointsHash(ctx, f, hasher, state.Offset, state.FileSize, state.ChunkSize, state.HashValues.clone())
+}
+
+func (dh *defaultHash) resumeCheckpointsHash(ctx context.Context, f io.Reader, h hash.Hash, offset int64, fileSize int64, chunkSize int64, hvs HashValues) (HashValues, *HashState, error) {
+	if err := dh.checkTextEncoding(); err != nil {
+		return nil, nil, err
+	}
+	offset, err := dh.calcHashValuesWithFileOffset(ctx, f, h, offset, fileSize, chunkSize, hvs)
+	if errors.Is(err, errMmapFault) {
+		// the hash state is broken by the partially written data
+		return hvs, nil, err
+	}
+	state, stateErr := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if stateErr != nil {
+		return hvs, nil, errors.Join(err, stateErr)
+	}
+	snapshot := &HashState{
+		Algorithm:  dh.algorithm,
+		ChunkSize:  chunkSize,
+		FileSize:   fileSize,
+		Offset:     offset,
+		State:      state,
+		HashValues: hvs.clone(),
+	}
+	return hvs, snapshot, err
+}
@@ -0,0 +1,187 @@
+package hashutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash"
+	"os"
+	"testing"
+)
+
+func TestResumeCheckpointsHashFromFile(t *testing.T) {
+	var chunkSize int64 = 20
+	checkpointCount := 10
+	expect, err := testHash.CheckpointsHashFromFileName(testFilePath, chunkSize, checkpointCount)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var interruptAt int64 = 1000
+	h, err := NewHash(DefaultHash, WithProgress(ProgressFuncs{
+		Progress: func(processed int64, total int64) {
+			if processed >= interruptAt {
+				cancel()
+			}
+		},
+	}))
+	if err != nil {
+		t.Errorf("init hash component error => %v", err)
+		return
+	}
+
+	f, err := os.Open(testFilePath)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f.Close()
+
+	_, snapshot, err := ResumableCheckpointsHashFromFileContext(ctx, h, f, chunkSize, checkpointCount)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("test ResumableCheckpointsHashFromFileContext error, expect to get error %v, but actual get %v", context.Canceled, err)
+		return
+	}
+	if snapshot == nil || snapshot.Offset < interruptAt || snapshot.Offset >= snapshot.FileSize {
+		t.Errorf("test ResumableCheckpointsHashFromFileContext error, expect to get the snapshot of the interrupted hashing")
+		return
+	}
+
+	// persist the snapshot and resume from it with a new file reader
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Errorf("marshal hash state error => %v", err)
+		return
+	}
+	var state HashState
+	if err = json.Unmarshal(data, &state); err != nil {
+		t.Errorf("unmarshal hash state error => %v", err)
+		return
+	}
+	resumeFile, err := os.Open(testFilePath)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer resumeFile.Close()
+
+	actual, snapshot, err := ResumeCheckpointsHashFromFile(testHash, resumeFile, &state)
+	if err != nil {
+		t.Errorf("test ResumeCheckpointsHashFromFile error => %v", err)
+		return
+	}
+	if snapshot == nil || snapshot.Offset != snapshot.FileSize {
+		t.Errorf("test ResumeCheckpointsHashFromFile error, expect to get the snapshot of the completed hashing")
+	}
+	if len(actual) != len(expect) {
+		t.Errorf("test ResumeCheckpointsHashFromFile error, expect to get %d HashValues, but actual get %d", len(expect), len(actual))
+		return
+	}
+	for i := range expect {
+		if actual[i].Offset != expect[i].Offset || actual[i].Hash != expect[i].Hash {
+			t.Errorf("test ResumeCheckpointsHashFromFile error, expect:[%d => %s] actual:[%d => %s]", expect[i].Offset, expect[i].Hash, actual[i].Offset, actual[i].Hash)
+		}
+	}
+}
+
+func TestResumableCheckpointsHashFromFile_ReturnError(t *testing.T) {
+	name := "custom-without-state"
+	if err := RegisterHash(name, func() hash.Hash { return withoutStateHash{testHash.new()} }); err != nil {
+		t.Errorf("register hash error => %v", err)
+		return
+	}
+	defer Unregister(name)
+	withoutStateHash, err := NewHash(name)
+	if err != nil {
+		t.Errorf("init hash component error => %v", err)
+		return
+	}
+
+	f, err := os.Open(testFilePath)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f.Close()
+
+	testCases := []struct {
+		name string
+		h    Hash
+		f    *os.File
+	}{
+		{"nil file", testHash, nil},
+		{"unsupported hasher", withoutStateHash, f},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := ResumableCheckpointsHashFromFile(tc.h, tc.f, 20, 10); err == nil {
+				t.Errorf("test ResumableCheckpointsHashFromFile error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestResumeCheckpointsHashFromFile_ReturnError(t *testing.T) {
+	f, err := os.Open(testFilePath)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name  string
+		f     *os.File
+		state *HashState
+	}{
+		{"nil file", nil, &HashState{Algorithm: DefaultHash}},
+		{"nil state", f, nil},
+		{"different algorithm", f, &HashState{Algorithm: SHA1Hash, FileSize: stat.Size()}},
+		{"file size changed", f, &HashState{Algorithm: DefaultHash, FileSize: stat.Size() + 1}},
+		{"invalid state", f, &HashState{Algorithm: DefaultHash, FileSize: stat.Size(), State: []byte("invalid")}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := ResumeCheckpointsHashFromFile(testHash, tc.f, tc.state); err == nil {
+				t.Errorf("test ResumeCheckpointsHashFromFile error, expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+// withoutStateHash a hash.Hash that hides the encoding.BinaryMarshaler of the underlying hasher
+type withoutStateHash struct {
+	hash.Hash
+}
+
+func TestResumeCheckpointsHashFromFileContext_ReturnCanceledError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	f, err := os.Open(testFilePath)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f.Close()
+
+	_, snapshot, err := ResumableCheckpointsHashFromFileContext(ctx, testHash, f, 20, 10)
+	if !errors.Is(err, context.Canceled) || snapshot == nil {
+		t.Errorf("test ResumableCheckpointsHashFromFileContext error, expect to get error %v with the snapshot, but actual get %v", context.Canceled, err)
+		return
+	}
+	_, snapshot, err = ResumeCheckpointsHashFromFileContext(ctx, testHash, f, snapshot)
+	if !errors.Is(err, context.Canceled) || snapshot == nil {
+		t.Errorf("test ResumeCheckpointsHashFromFileContext error, expect to get error %v with the snapshot, but actual get %v", context.Canceled, err)
+	}
+}
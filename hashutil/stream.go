@@ -0,0 +1,173 @@
+package hashutil
+
+import (
+	"hash"
+	"io"
+)
+
+// HashWriter an io.Writer that calculates the hash value of the entire data and the checkpoint HashValues
+// while the data is written through it
+type HashWriter struct {
+	dh        *defaultHash
+	h         hash.Hash
+	size      int64
+	chunkSize int64
+	hvs       HashValues
+	hvi       int
+	written   int64
+	// boundary the chunk boundary that the last checkpoint is completed at
+	boundary int64
+	// eof whether the checkpoint at the end of the data is completed
+	eof bool
+}
+
+// HashReader an io.Reader that calculates the hash value of the entire data and the checkpoint HashValues
+// while the data is read through it, like a tee reader
+type HashReader struct {
+	w *HashWriter
+	r io.Reader
+}
+
+// NewHashWriter returns an io.Writer that calculates the hash value of the entire data and the checkpoint HashValues
+// with the h while the data is written, the size is the expected count of bytes to write, -1 means unknown and no checkpoint
+func NewHashWriter(h Hash, size int64, chunkSize int64, checkpointCount int) (w *HashWriter, err error) {
+	dh, err := toDefaultHash(h)
+	if err != nil {
+		return nil, err
+	}
+	if chunkSize < 0 {
+		return nil, errChunkSizeInvalid
+	}
+	w = &HashWriter{
+		dh:   dh,
+		h:    dh.new(),
+		size: size,
+	}
+	if size >= 0 {
+		w.hvs, w.chunkSize = buildHashValues(size, chunkSize, checkpointCount)
+	}
+	return w, nil
+}
+
+// NewHashReader returns an io.Reader that calculates the hash value of the entire data and the checkpoint HashValues
+// with the h while the data is read from the r, the size is the expected count of bytes to read, -1 means unknown and
+// no checkpoint
+func NewHashReader(h Hash, r io.Reader, size int64, chunkSize int64, checkpointCount int) (hr *HashReader, err error) {
+	if r == nil {
+		return nil, errNilFile
+	}
+	w, err := NewHashWriter(h, size, chunkSize, checkpointCount)
+	if err != nil {
+		return nil, err
+	}
+	return &HashReader{
+		w: w,
+		r: r,
+	}, nil
+}
+
+// Write writes the p to the hasher, and records the hash value when reaching the offset of every checkpoint.
+// The checkpoints are completed at the chunk boundaries like CheckpointsHashFromFile, so the HashValues are the same
+// as it if the count of bytes written equals the size
+func (w *HashWriter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	for len(p) > 0 && w.hvi < len(w.hvs) {
+		boundary, ok := w.nextBoundary()
+		next := boundary - w.written
+		if !ok || next > int64(len(p)) {
+			break
+		}
+		w.write(p[:next])
+		w.boundary = boundary
+		w.completeCheckpoint()
+		p = p[next:]
+	}
+	w.write(p)
+	if w.written == w.size {
+		w.completeEOF()
+	}
+	w.dh.progress.OnProgress(w.written, w.size)
+	return n, nil
+}
+
+// nextBoundary returns the chunk boundary to complete the next checkpoint at, that is the end of the chunk reaching
+// its offset. At most one checkpoint is completed at every chunk boundary, so the checkpoint with the same offset as
+// the previous one is moved to the next chunk boundary. The last chunk ends at the size, returns false if the previous
+// checkpoint is completed there
+func (w *HashWriter) nextBoundary() (boundary int64, ok bool) {
+	offset := w.hvs[w.hvi].Offset
+	boundary = max((offset+w.chunkSize-1)/w.chunkSize*w.chunkSize, w.boundary+w.chunkSize)
+	if boundary > w.size {
+		if w.boundary >= w.size {
+			return 0, false
+		}
+		boundary = w.size
+	}
+	return boundary, true
+}
+
+func (w *HashWriter) write(p []byte) {
+	w.h.Write(p)
+	w.written += int64(len(p))
+}
+
+func (w *HashWriter) completeCheckpoint() {
+	hv := w.hvs[w.hvi]
+	hv.Offset = w.written
+	hv.Hash = w.dh.encode(w.h.Sum(nil))
+	w.dh.progress.OnCheckpoint(hv)
+	w.hvi++
+}
+
+// completeEOF complete the next checkpoint when all the data is written, like CheckpointsHashFromFile completes
+// one more checkpoint when reaching the end of the file
+func (w *HashWriter) completeEOF() {
+	if !w.eof && w.hvi < len(w.hvs) && w.hvs[w.hvi].Offset <= w.written {
+		w.completeCheckpoint()
+	}
+	w.eof = true
+}
+
+// Written returns the count of bytes written so far
+func (w *HashWriter) Written() int64 {
+	return w.written
+}
+
+// Hash returns the hash value of the data written so far
+func (w *HashWriter) Hash() string {
+	return w.dh.encode(w.h.Sum(nil))
+}
+
+// HashValues returns the completed checkpoint HashValues, the last one is always the hash value of the data
+// written so far. If the size is unknown, only returns the hash value of the entire data
+func (w *HashWriter) HashValues() HashValues {
+	// complete the checkpoint at the end of the empty data
+	if w.written == w.size {
+		w.completeEOF()
+	}
+	hvs := w.hvs[:w.hvi].clone()
+	if last := hvs.Last(); last == nil || last.Offset != w.written {
+		hvs = append(hvs, NewHashValue(w.written, w.Hash()))
+	}
+	return hvs
+}
+
+// Read reads the data from the underlying reader and writes it to the hasher
+func (r *HashReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	if n > 0 {
+		r.w.Write(p[:n])
+	}
+	return n, err
+}
+
+// Hash returns the hash value of the data read so far
+func (r *HashReader) Hash() string {
+	return r.w.Hash()
+}
+
+// HashValues returns the completed checkpoint HashValues like HashWriter.HashValues, the last one is always
+// the hash value of the data read so far
+func (r *HashReader) HashValues() HashValues {
+	return r.w.HashValues()
+}
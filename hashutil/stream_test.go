@@ -0,0 +1,179 @@
+package hashutil
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHashWriter(t *testing.T) {
+	fileData, err := os.ReadFile(testFilePath)
+	if err != nil {
+		t.Errorf("read file error => %v", err)
+		return
+	}
+	fileSize := int64(len(fileData))
+	fileData = bytes.Repeat(fileData, 2)
+
+	testCases := []struct {
+		name            string
+		size            int64
+		chunkSize       int64
+		checkpointCount int
+		bufferSize      int
+	}{
+		{"checkpoints with small buffer", fileSize, 20, 10, 7},
+		{"checkpoints with large buffer", fileSize, 20, 10, 4096},
+		{"default chunk size", fileSize, 0, 10, 100},
+		{"only entire file", fileSize, 0, 0, 100},
+		{"duplicate checkpoint offsets", 100, 20, 10, 7},
+		{"duplicate checkpoint offsets with large buffer", 100, 20, 10, 4096},
+		{"duplicate checkpoint offsets at the end", 6786, 2487, 8, 1000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			size := tc.size
+			data := fileData[:size]
+			path := filepath.Join(t.TempDir(), "data")
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Errorf("write file error => %v", err)
+				return
+			}
+			expect, err := testHash.CheckpointsHashFromFileName(path, tc.chunkSize, tc.checkpointCount)
+			if err != nil {
+				t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+				return
+			}
+			w, err := NewHashWriter(testHash, size, tc.chunkSize, tc.checkpointCount)
+			if err != nil {
+				t.Errorf("test NewHashWriter error => %v", err)
+				return
+			}
+			if _, err = io.CopyBuffer(struct{ io.Writer }{w}, bytes.NewReader(data), make([]byte, tc.bufferSize)); err != nil {
+				t.Errorf("write data error => %v", err)
+				return
+			}
+			hvs := w.HashValues()
+			testHashValuesEqual(t, expect, hvs)
+			if w.Written() != size || w.Hash() != expect.Last().Hash {
+				t.Errorf("test HashWriter error, expect to write %d bytes with hash %s, but actual write %d bytes with hash %s", size, expect.Last().Hash, w.Written(), w.Hash())
+			}
+			chunkSize := tc.chunkSize
+			if chunkSize == 0 {
+				chunkSize = defaultChunkSize
+			}
+			if equal, hv := testHash.CompareHashValues(path, size, w.Hash(), chunkSize, hvs); !equal {
+				t.Errorf("test CompareHashValues with the HashValues of the HashWriter error, expect to be equal, but actual get the last match %v", hv)
+			}
+		})
+	}
+}
+
+func TestHashWriter_SizeMismatch(t *testing.T) {
+	data := bytes.Repeat([]byte("hello gopher"), 100)
+	testCases := []struct {
+		name   string
+		size   int64
+		expect int
+	}{
+		{"unknown size", -1, 1},
+		{"less than expected", int64(len(data) * 2), 6},
+		{"more than expected", 100, 6},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewHashWriter(testHash, tc.size, 20, 10)
+			if err != nil {
+				t.Errorf("test NewHashWriter error => %v", err)
+				return
+			}
+			w.Write(data)
+			hvs := w.HashValues()
+			if len(hvs) != tc.expect {
+				t.Errorf("test HashWriter error, expect to get %d HashValues, but actual get %d", tc.expect, len(hvs))
+				return
+			}
+			if hvs.Last().Offset != int64(len(data)) || hvs.Last().Hash != testHash.Hash(data) {
+				t.Errorf("test HashWriter error, expect the last HashValue is the hash of the entire data")
+			}
+		})
+	}
+}
+
+func TestHashWriter_EmptyData(t *testing.T) {
+	w, err := NewHashWriter(testHash, 0, 20, 10)
+	if err != nil {
+		t.Errorf("test NewHashWriter error => %v", err)
+		return
+	}
+	hvs := w.HashValues()
+	if len(hvs) != 1 || hvs.Last().Offset != 0 || hvs.Last().Hash != testHash.Hash(nil) {
+		t.Errorf("test HashWriter error, expect to get the hash value of the empty data")
+	}
+}
+
+func TestHashReader(t *testing.T) {
+	expect, err := testHash.CheckpointsHashFromFileName(testFilePath, 20, 10)
+	if err != nil {
+		t.Errorf("test CheckpointsHashFromFileName error => %v", err)
+		return
+	}
+	f, err := os.Open(testFilePath)
+	if err != nil {
+		t.Errorf("open file error => %v", err)
+		return
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		t.Errorf("get file stat error => %v", err)
+		return
+	}
+
+	r, err := NewHashReader(testHash, iotest.OneByteReader(f), stat.Size(), 20, 10)
+	if err != nil {
+		t.Errorf("test NewHashReader error => %v", err)
+		return
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Errorf("read data error => %v", err)
+		return
+	}
+	if int64(len(data)) != stat.Size() {
+		t.Errorf("test HashReader error, expect to read %d bytes, but actual read %d", stat.Size(), len(data))
+	}
+	testHashValuesEqual(t, expect, r.HashValues())
+	if r.Hash() != expect.Last().Hash {
+		t.Errorf("test HashReader error, expect to get hash %s, but actual get %s", expect.Last().Hash, r.Hash())
+	}
+	if _, ok := any(r).(io.Writer); ok {
+		t.Errorf("test HashReader error, expect the HashReader is not an io.Writer")
+	}
+}
+
+func TestNewHashReader_ReturnError(t *testing.T) {
+	if _, err := NewHashReader(testHash, nil, 10, 20, 10); err == nil {
+		t.Errorf("test NewHashReader error, expect to get an error with nil reader but get nil")
+	}
+	if _, err := NewHashReader(testHash, bytes.NewReader(nil), 10, -1, 10); err == nil {
+		t.Errorf("test NewHashReader error, expect to get an error with invalid chunk size but get nil")
+	}
+}
+
+func testHashValuesEqual(t *testing.T, expect HashValues, actual HashValues) {
+	if len(actual) != len(expect) {
+		t.Errorf("expect to get %d HashValues, but actual get %d", len(expect), len(actual))
+		return
+	}
+	for i := range expect {
+		if actual[i].Offset != expect[i].Offset || actual[i].Hash != expect[i].Hash {
+			t.Errorf("expect:[%d => %s] actual:[%d => %s]", expect[i].Offset, expect[i].Hash, actual[i].Offset, actual[i].Hash)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/no-src/nsgo/fsutil"
 	"github.com/quic-go/quic-go/http3"
 )
 
@@ -164,11 +165,16 @@ func (c *httpClient) Download(path, url string, alwaysDownload bool) error {
 		return err
 	}
 	defer resp.Body.Close()
-	data, err := io.ReadAll(resp.Body)
+	// write to a temporary file and replace the local file after the download completes, avoid the half-written file
+	w, err := fsutil.NewAtomicWriter(path, fs.ModePerm)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, fs.ModePerm)
+	defer w.Abort()
+	if _, err = io.Copy(w, resp.Body); err != nil {
+		return err
+	}
+	return w.Commit()
 }
 
 func (c *httpClient) HttpPostData(url string, data []byte) (resp *http.Response, err error) {